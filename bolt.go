@@ -0,0 +1,361 @@
+package cravings
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBuckets is every bucket the bolt database needs, the collections and the name indexes
+var boltBuckets = []string{RecipeCollection, IngredientCollection, WebhooksCollection, TokenCollection,
+	RecipeNameIndex, IngredientNameIndex}
+
+// Init opens the database file, creates it if it does not exist, and creates the buckets
+func (db *BoltDatabase) Init() error {
+	var err error
+
+	// Timeout so a second process using the same file fails instead of waiting forever for the lock
+	db.db, err = bolt.Open(db.Path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return errors.Wrap(err, "Could not open bolt database "+db.Path)
+	}
+
+	return db.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range boltBuckets {
+			_, err := tx.CreateBucketIfNotExists([]byte(name))
+			if err != nil {
+				return errors.Wrap(err, "Could not create bucket "+name)
+			}
+		}
+
+		return nil
+	})
+}
+
+// boltNameKey is the key of a document in a name index. Every document with the name has its own key, and the
+// one with the lowest id is found first, like in firestore
+func boltNameKey(name string, id string) []byte {
+	return []byte(name + "\x00" + id)
+}
+
+// Close closes the database file
+func (db *BoltDatabase) Close() error {
+	if db.db == nil {
+		return errors.New("bolt database was never opened")
+	}
+
+	return db.db.Close()
+}
+
+// boltPut encodes v as json and stores it under id in the bucket
+func boltPut(tx *bolt.Tx, bucket string, id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket([]byte(bucket)).Put([]byte(id), data)
+}
+
+// SaveRecipe saves recipe to the bolt database and indexes it by name
+func (db *BoltDatabase) SaveRecipe(r *Recipe) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		r.ID = newID()
+
+		err := boltPut(tx, RecipeCollection, r.ID, r)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(RecipeNameIndex)).Put(boltNameKey(r.RecipeName, r.ID), []byte(r.ID))
+	})
+}
+
+// SaveIngredient saves ingredient to the bolt database and indexes it by name
+func (db *BoltDatabase) SaveIngredient(i *Ingredient) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		i.ID = newID()
+
+		err := boltPut(tx, IngredientCollection, i.ID, i)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(IngredientNameIndex)).Put(boltNameKey(i.Name, i.ID), []byte(i.ID))
+	})
+}
+
+// SaveWebhook saves webhook to the bolt database
+func (db *BoltDatabase) SaveWebhook(wh *Webhook) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		wh.ID = newID()
+		return boltPut(tx, WebhooksCollection, wh.ID, wh)
+	})
+}
+
+// SaveToken adds an approved token to the bolt database
+func (db *BoltDatabase) SaveToken(t *Token) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		t.ID = newID()
+		return boltPut(tx, TokenCollection, t.ID, t)
+	})
+}
+
+// Delete deletes an entry from given collection by its id, and removes it from the name index
+func (db *BoltDatabase) Delete(id string, collection string) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(collection))
+		if b == nil {
+			return errors.New("No collection named " + collection)
+		}
+
+		data := b.Get([]byte(id))
+		if data == nil {
+			return errors.New("No document with id \"" + id + "\" in " + collection)
+		}
+
+		name := ""
+		index := ""
+
+		switch collection { // find the name the document is indexed by
+		case RecipeCollection:
+			r := Recipe{}
+			if err := json.Unmarshal(data, &r); err != nil {
+				return err
+			}
+
+			name, index = r.RecipeName, RecipeNameIndex
+		case IngredientCollection:
+			i := Ingredient{}
+			if err := json.Unmarshal(data, &i); err != nil {
+				return err
+			}
+
+			name, index = i.Name, IngredientNameIndex
+		}
+
+		// Other documents with the same name keep their own index entries
+		if index != "" {
+			if err := tx.Bucket([]byte(index)).Delete(boltNameKey(name, id)); err != nil {
+				return err
+			}
+		}
+
+		return b.Delete([]byte(id))
+	})
+}
+
+// get looks up the lowest id for name in the index bucket and decodes that document into v
+func (db *BoltDatabase) get(index string, collection string, name string, v interface{}) (bool, error) {
+	found := false
+
+	err := db.db.View(func(tx *bolt.Tx) error {
+		prefix := boltNameKey(name, "")
+
+		k, id := tx.Bucket([]byte(index)).Cursor().Seek(prefix)
+		if k == nil || !bytes.HasPrefix(k, prefix) {
+			return nil
+		}
+
+		data := tx.Bucket([]byte(collection)).Get(id)
+		if data == nil {
+			return nil
+		}
+
+		found = true
+
+		return json.Unmarshal(data, v)
+	})
+
+	return found, err
+}
+
+// ReadRecipeByName reads a single recipe by name through the recipe name index
+func (db *BoltDatabase) ReadRecipeByName(name string) (Recipe, error) {
+	r := Recipe{}
+
+	found, err := db.get(RecipeNameIndex, RecipeCollection, name, &r)
+	if err != nil {
+		return r, err
+	}
+
+	if !found {
+		return r, &NotFoundError{Kind: "recipe", Name: name}
+	}
+
+	return r, nil
+}
+
+// ReadIngredientByName reads a single ingredient by name through the ingredient name index
+func (db *BoltDatabase) ReadIngredientByName(name string) (Ingredient, error) {
+	i := Ingredient{}
+
+	found, err := db.get(IngredientNameIndex, IngredientCollection, name, &i)
+	if err != nil {
+		return i, err
+	}
+
+	if !found {
+		return i, &NotFoundError{Kind: "ingredient", Name: name}
+	}
+
+	return i, nil
+}
+
+// forEach calls fn with every document in the bucket
+func (db *BoltDatabase) forEach(bucket string, fn func(data []byte) error) error {
+	return db.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucket)).ForEach(func(k, v []byte) error {
+			return fn(v)
+		})
+	})
+}
+
+// ReadAllRecipes reads all recipes from the bolt database
+func (db *BoltDatabase) ReadAllRecipes() ([]Recipe, error) {
+	var temprecipes []Recipe
+
+	err := db.forEach(RecipeCollection, func(data []byte) error {
+		r := Recipe{}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+
+		temprecipes = append(temprecipes, r)
+
+		return nil
+	})
+
+	return temprecipes, err
+}
+
+// ReadAllIngredients reads all ingredients from the bolt database
+func (db *BoltDatabase) ReadAllIngredients() ([]Ingredient, error) {
+	var tempingredients []Ingredient
+
+	err := db.forEach(IngredientCollection, func(data []byte) error {
+		i := Ingredient{}
+		if err := json.Unmarshal(data, &i); err != nil {
+			return err
+		}
+
+		tempingredients = append(tempingredients, i)
+
+		return nil
+	})
+
+	return tempingredients, err
+}
+
+// page calls fn with at most limit documents in the bucket after the cursor, in the order of their ids,
+// and returns the cursor of the next page
+func (db *BoltDatabase) page(bucket string, cursor string, limit int, fn func(data []byte) error) (string, error) {
+	next := ""
+
+	err := db.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(bucket)).Cursor()
+
+		k, v := c.First()
+		if cursor != "" {
+			k, v = c.Seek([]byte(cursor))
+			if k != nil && string(k) == cursor {
+				k, v = c.Next()
+			}
+		}
+
+		for n := 0; k != nil; k, v = c.Next() {
+			if n == limit { // there are more documents after this page
+				next = cursor
+				return nil
+			}
+
+			if err := fn(v); err != nil {
+				return err
+			}
+
+			cursor = string(k)
+			n++
+		}
+
+		return nil
+	})
+
+	return next, err
+}
+
+// ReadRecipesPage reads a page of recipes ordered by ID, see Store
+func (db *BoltDatabase) ReadRecipesPage(cursor string, limit int) ([]Recipe, string, error) {
+	temprecipes := []Recipe{}
+
+	next, err := db.page(RecipeCollection, cursor, limit, func(data []byte) error {
+		r := Recipe{}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+
+		temprecipes = append(temprecipes, r)
+
+		return nil
+	})
+
+	return temprecipes, next, err
+}
+
+// ReadIngredientsPage reads a page of ingredients ordered by ID, see Store
+func (db *BoltDatabase) ReadIngredientsPage(cursor string, limit int) ([]Ingredient, string, error) {
+	tempingredients := []Ingredient{}
+
+	next, err := db.page(IngredientCollection, cursor, limit, func(data []byte) error {
+		i := Ingredient{}
+		if err := json.Unmarshal(data, &i); err != nil {
+			return err
+		}
+
+		tempingredients = append(tempingredients, i)
+
+		return nil
+	})
+
+	return tempingredients, next, err
+}
+
+// ReadAllWebhooks reads all webhooks from the bolt database
+func (db *BoltDatabase) ReadAllWebhooks() ([]Webhook, error) {
+	var tempWebhooks []Webhook
+
+	err := db.forEach(WebhooksCollection, func(data []byte) error {
+		wh := Webhook{}
+		if err := json.Unmarshal(data, &wh); err != nil {
+			return err
+		}
+
+		tempWebhooks = append(tempWebhooks, wh)
+
+		return nil
+	})
+
+	return tempWebhooks, err
+}
+
+// CheckToken returns true if token is in the tokens bucket
+func (db *BoltDatabase) CheckToken(token string) (bool, error) {
+	found := false
+
+	err := db.forEach(TokenCollection, func(data []byte) error {
+		t := Token{}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return err
+		}
+
+		if t.AuthToken == token {
+			found = true
+		}
+
+		return nil
+	})
+
+	return found, err
+}
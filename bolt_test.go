@@ -0,0 +1,111 @@
+package cravings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBoltDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cravings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := &BoltDatabase{Path: filepath.Join(dir, "test.db")}
+
+	err = db.Init() // test creating the database file
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ing := Ingredient{Name: "milk", Unit: "l", Quantity: 1}
+
+	err = db.SaveIngredient(&ing) // test saving ingredient
+	if err != nil {
+		t.Error(err)
+	}
+
+	rec := Recipe{RecipeName: "TestRecipe", Ingredients: []Ingredient{ing}}
+
+	err = db.SaveRecipe(&rec) // test saving recipe
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.SaveToken(&Token{AuthToken: "testtoken"}) // test saving token
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Init() // everything should still be there after opening the file again
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ing2, err := db.ReadIngredientByName("milk") // test reading ingredient through the index
+	if err != nil {
+		t.Error(err)
+	}
+
+	if ing2.ID != ing.ID {
+		t.Error("read wrong ingredient", ing2)
+	}
+
+	rec2, err := db.ReadRecipeByName("TestRecipe") // test reading recipe through the index
+	if err != nil {
+		t.Error(err)
+	}
+
+	if rec2.ID != rec.ID || len(rec2.Ingredients) != 1 {
+		t.Error("read wrong recipe", rec2)
+	}
+
+	ok, err := db.CheckToken("testtoken")
+	if !ok || err != nil {
+		t.Error("valid token was not accepted", err)
+	}
+
+	twin := Ingredient{Name: "milk", Unit: "l", Quantity: 2}
+
+	err = db.SaveIngredient(&twin) // a second ingredient with the same name gets its own index entry
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = db.Delete(ing.ID, IngredientCollection) // test deleting only removes the index entry of the document
+	if err != nil {
+		t.Error(err)
+	}
+
+	ing2, err = db.ReadIngredientByName("milk")
+	if err != nil || ing2.ID != twin.ID {
+		t.Error("ingredient with the same name as a deleted one was not found", ing2, err)
+	}
+
+	err = db.Delete(twin.ID, IngredientCollection)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = db.ReadIngredientByName("milk")
+	if err == nil {
+		t.Error("deleted ingredient was found by name")
+	}
+
+	err = db.Delete("", WebhooksCollection) // test deleting something that dont exist, error is supposed to be sent
+	if err == nil {
+		t.Error("deleted a webhook that does not exist")
+	}
+
+	fmt.Println("testing BoltDatabase")
+}
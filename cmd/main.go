@@ -6,13 +6,23 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 )
 
 func main() {
 	cravings.StartTime = time.Now() // sets StartTime
 
-	err := cravings.DBInit()
+	if file := os.Getenv("DATABASE_FILE"); file != "" {
+		cravings.BoltFile = file // path to the bolt database file
+	}
+
+	err := cravings.SelectDatabase(os.Getenv("DATABASE")) // firestore if not set
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = cravings.DBInit()
 
 	if err != nil {
 		fmt.Println("Failed to initialize database")
@@ -20,6 +30,72 @@ func main() {
 		fmt.Println("Database init OK")
 	}
 
+	// Databases other than firestore can get an approved token at startup, it is only saved the first time
+	if token := os.Getenv("TOKEN"); token != "" {
+		if saver, ok := cravings.Database.(cravings.TokenSaver); ok {
+			saved, err := cravings.Database.CheckToken(token)
+			if err == nil && !saved {
+				err = saver.SaveToken(&cravings.Token{AuthToken: token})
+			}
+
+			if err != nil {
+				fmt.Println("Failed to save token: " + err.Error())
+			}
+		}
+	}
+
+	// Bootstrap the ingredients collection from a USDA food composition dataset
+	if file := os.Getenv("IMPORT_FILE"); file != "" {
+		saved, err := cravings.ImportNutrientFile(file)
+		if err != nil {
+			fmt.Println("Failed to import " + file + ": " + err.Error())
+		}
+
+		fmt.Println("Imported", saved, "ingredients from", file)
+	}
+
+	if file := os.Getenv("NUTRIENT_FILE"); file != "" {
+		cravings.NutrientFile = file // path to the local nutrient table
+	}
+
+	err = cravings.SelectNutritionProvider(os.Getenv("NUTRITION")) // edamam if not set
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Calls allowed to Edamam, the defaults are in globalVariables.go
+	perMinute, err := strconv.Atoi(os.Getenv("EDAMAM_PER_MINUTE"))
+	if err != nil {
+		perMinute = cravings.EdamamCallsPerMinute
+	}
+
+	perDay, err := strconv.Atoi(os.Getenv("EDAMAM_PER_DAY"))
+	if err != nil {
+		perDay = cravings.EdamamCallsPerDay
+	}
+
+	cravings.SetEdamamQuota(perMinute, perDay)
+
+	// Timeouts for outbound calls, i.e. EDAMAM_TIMEOUT=5s
+	for destination, env := range map[string]string{
+		cravings.OutboundEdamam: "EDAMAM_TIMEOUT", cravings.OutboundWebhook: "WEBHOOK_TIMEOUT"} {
+		if timeout, err := time.ParseDuration(os.Getenv(env)); err == nil {
+			cravings.SetOutboundTimeout(destination, timeout)
+		}
+	}
+
+	// Cache nutrients so repeated lookups do not use up the Edamam quota, unless NUTRIENT_CACHE=off
+	if file := os.Getenv("NUTRIENT_CACHE"); file != "off" {
+		if file != "" {
+			cravings.NutrientCacheFile = file
+		}
+
+		err = cravings.EnableNutrientCache(cravings.NutrientCacheFile, cravings.NutrientCacheTTL)
+		if err != nil {
+			fmt.Println("Failed to load nutrient cache: " + err.Error())
+		}
+	}
+
 	err = cravings.InitAPICredentials()
 
 	if err != nil {
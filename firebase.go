@@ -2,11 +2,9 @@ package cravings
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 
+	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go"
 	"github.com/pkg/errors"
 	"google.golang.org/api/iterator"
@@ -16,21 +14,21 @@ import (
 // fireBaseDB is an instance of FirestoreDatabase struct which is used in firebase.go
 var fireBaseDB = FirestoreDatabase{}
 
-// DBInit initialises the database
-func DBInit() error {
+// Init initialises the firestore database
+func (db *FirestoreDatabase) Init() error {
 	// Firebase initialisation
-	fireBaseDB.Ctx = context.Background()
+	db.Ctx = context.Background()
 	// We use a service account, load credentials file that you downloaded from your project's settings menu.
 	// Make sure this file is gitignored, it is the access token to the database.
 	sa := option.WithCredentialsFile(FirestoreCredentials)
-	app, err := firebase.NewApp(fireBaseDB.Ctx, nil, sa) //  Creates the application with its contents
+	app, err := firebase.NewApp(db.Ctx, nil, sa) //  Creates the application with its contents
 
 	if err != nil {
 		fmt.Println("Failed to initialize the firebase database when creating a new app: ", err)
 		return err
 	}
 	//  Sets the app created to our local struct's client
-	fireBaseDB.Client, err = app.Firestore(fireBaseDB.Ctx)
+	db.Client, err = app.Firestore(db.Ctx)
 	if err != nil {
 		fmt.Println("Failed to create app")
 		return err
@@ -39,21 +37,93 @@ func DBInit() error {
 	return err
 }
 
-// DBClose Close firebase connection
-func DBClose() {
-	err := fireBaseDB.Client.Close()
-	if err != nil {
-		fmt.Println("Failed to close firebase client")
-	} else {
-		fmt.Println("Successfully closed firebase client")
+// Close closes the firebase connection
+func (db *FirestoreDatabase) Close() error {
+	if db.Client == nil {
+		return errors.New("firebase client was never initialised")
+	}
+
+	if db.stopWatch != nil {
+		db.stopWatch()
+	}
+
+	return db.Client.Close()
+}
+
+// Watch listens for changes to the recipes and ingredients in firestore, and calls notify for every document
+// that is added, changed or removed, also by other instances of the program
+func (db *FirestoreDatabase) Watch(notify func(ChangeEvent)) error {
+	if db.Client == nil {
+		return errors.New("firebase client was never initialised")
+	}
+
+	ctx, cancel := context.WithCancel(db.Ctx)
+	db.stopWatch = cancel
+
+	go db.watchCollection(ctx, RecipeCollection, notify)
+	go db.watchCollection(ctx, IngredientCollection, notify)
+
+	return nil
+}
+
+// watchCollection calls notify for every change to the collection until ctx is cancelled
+func (db *FirestoreDatabase) watchCollection(ctx context.Context, collection string, notify func(ChangeEvent)) {
+	iter := db.Client.Collection(collection).Snapshots(ctx)
+	defer iter.Stop()
+
+	first := true
+
+	for {
+		snapshot, err := iter.Next()
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Println("Stopped listening for changes to " + collection + ": " + err.Error())
+			}
+
+			return
+		}
+
+		if first { // the first snapshot is every document, anything read before it may have changed since
+			first = false
+
+			notify(ChangeEvent{})
+
+			continue
+		}
+
+		for _, change := range snapshot.Changes {
+			event := ChangeEvent{Collection: collection, ID: change.Doc.Ref.ID, Deleted: change.Kind == firestore.DocumentRemoved}
+
+			if !event.Deleted {
+				switch collection {
+				case RecipeCollection:
+					event.Recipe = &Recipe{}
+					err = change.Doc.DataTo(event.Recipe)
+					event.Recipe.ID = event.ID
+				case IngredientCollection:
+					event.Ingredient = &Ingredient{}
+					err = change.Doc.DataTo(event.Ingredient)
+					event.Ingredient.ID = event.ID
+				}
+
+				if err != nil {
+					fmt.Println("Could not read changed document " + event.ID + " in " + collection + ": " + err.Error())
+					notify(ChangeEvent{}) // read everything again, instead of missing the change
+
+					continue
+				}
+			}
+
+			notify(event)
+		}
 	}
 }
 
-// DBSaveRecipe saves recipe to database
-func DBSaveRecipe(r *Recipe, w http.ResponseWriter) error { //  Creates a new document in firebase
-	ref := fireBaseDB.Client.Collection(RecipeCollection).NewDoc()
-	r.ID = ref.ID                        //  Asserts the recipes id to be the one given by firebase
-	_, err := ref.Set(fireBaseDB.Ctx, r) //  Set the context of the document to the one of the recipe
+// SaveRecipe saves recipe to firestore
+func (db *FirestoreDatabase) SaveRecipe(r *Recipe) error { //  Creates a new document in firebase
+	ref := db.Client.Collection(RecipeCollection).NewDoc()
+	r.ID = ref.ID                //  Asserts the recipes id to be the one given by firebase
+	_, err := ref.Set(db.Ctx, r) //  Set the context of the document to the one of the recipe
 
 	if err != nil {
 		return err
@@ -62,11 +132,11 @@ func DBSaveRecipe(r *Recipe, w http.ResponseWriter) error { //  Creates a new do
 	return nil
 }
 
-// DBSaveIngredient saves ingredient to database
-func DBSaveIngredient(i *Ingredient, w http.ResponseWriter) error { //  Creates a new document in firebase
-	ref := fireBaseDB.Client.Collection(IngredientCollection).NewDoc()
-	i.ID = ref.ID                        //  Asserts the ingredients id to be the one given by firebase
-	_, err := ref.Set(fireBaseDB.Ctx, i) //  Set the context of the document to the one of the ingredient
+// SaveIngredient saves ingredient to firestore
+func (db *FirestoreDatabase) SaveIngredient(i *Ingredient) error { //  Creates a new document in firebase
+	ref := db.Client.Collection(IngredientCollection).NewDoc()
+	i.ID = ref.ID                //  Asserts the ingredients id to be the one given by firebase
+	_, err := ref.Set(db.Ctx, i) //  Set the context of the document to the one of the ingredient
 
 	if err != nil {
 		return err
@@ -75,11 +145,11 @@ func DBSaveIngredient(i *Ingredient, w http.ResponseWriter) error { //  Creates
 	return nil
 }
 
-// DBSaveWebhook saves a new webhook to the database
-func DBSaveWebhook(i *Webhook, w http.ResponseWriter) error {
-	ref := fireBaseDB.Client.Collection(WebhooksCollection).NewDoc()
-	i.ID = ref.ID                        //  Asserts the webhooks id to be the one given by firebase
-	_, err := ref.Set(fireBaseDB.Ctx, i) //  Set the context of the document to the one of the webhook
+// SaveWebhook saves a new webhook to firestore
+func (db *FirestoreDatabase) SaveWebhook(i *Webhook) error {
+	ref := db.Client.Collection(WebhooksCollection).NewDoc()
+	i.ID = ref.ID                //  Asserts the webhooks id to be the one given by firebase
+	_, err := ref.Set(db.Ctx, i) //  Set the context of the document to the one of the webhook
 
 	if err != nil {
 		return err
@@ -88,9 +158,9 @@ func DBSaveWebhook(i *Webhook, w http.ResponseWriter) error {
 	return nil
 }
 
-// DBDelete deletes an entry from given collection in database by its id, either ingredient, recipe or webhook
-func DBDelete(id string, collection string, w http.ResponseWriter) error {
-	_, err := fireBaseDB.Client.Collection(collection).Doc(id).Delete(fireBaseDB.Ctx)
+// Delete deletes an entry from given collection in firestore by its id, either ingredient, recipe or webhook
+func (db *FirestoreDatabase) Delete(id string, collection string) error {
+	_, err := db.Client.Collection(collection).Doc(id).Delete(db.Ctx)
 	if err != nil {
 		return errors.Wrap(err, "Error in FirebaseDatabase.Delete()")
 	}
@@ -98,66 +168,128 @@ func DBDelete(id string, collection string, w http.ResponseWriter) error {
 	return nil
 }
 
-// DBReadRecipeByName reads a single recipe by Name
-func DBReadRecipeByName(name string, w http.ResponseWriter) (Recipe, error) {
-	temp := Recipe{}                   //  Recipe to be returned
-	allrec, err := DBReadAllRecipes(w) //  Query all the recipes
+// ReadRecipeByName reads a single recipe by Name, with a query on the name field
+func (db *FirestoreDatabase) ReadRecipeByName(name string) (Recipe, error) {
+	temp := Recipe{} //  Recipe to be returned
 
+	found, err := db.readByName(RecipeCollection, RecipeNameField, name, &temp)
 	if err != nil {
 		return temp, err
 	}
-	//  Loops through all the recipes and checks if the parameter name is equal to one of the recipes
-	for _, i := range allrec {
-		if i.RecipeName == name { //  If recipe is found, return
-			temp.ID = i.ID
-			temp.RecipeName = i.RecipeName
-			temp.Ingredients = i.Ingredients
-			temp.Description = i.Description
-			temp.AllNutrients = i.AllNutrients
-
-			return temp, err
-		}
-	}
 
-	err = errors.New("No recipe named " + name + " in database")
+	if !found {
+		return temp, &NotFoundError{Kind: "recipe", Name: name}
+	}
 
-	return temp, err
+	return temp, nil
 }
 
-// DBReadIngredientByName reads a ingredient recipe by name
-func DBReadIngredientByName(name string, w http.ResponseWriter) (Ingredient, error) {
-	alling, err := DBReadAllIngredients(w) // Get all ingredients
+// ReadIngredientByName reads a single ingredient by name, with a query on the name field
+func (db *FirestoreDatabase) ReadIngredientByName(name string) (Ingredient, error) {
 	temp := Ingredient{}
 
+	found, err := db.readByName(IngredientCollection, IngredientNameField, name, &temp)
 	if err != nil {
 		return temp, err
 	}
 
-	for _, i := range alling {
-		if i.Name == name { // If name of parameter is in DB, return
-			temp.ID = i.ID
-			temp.Name = i.Name
-			temp.Nutrients = i.Nutrients
-			temp.Calories = i.Calories
-			temp.Weight = i.Weight
-			temp.Unit = i.Unit
-			temp.Weight = i.Weight
-			temp.Quantity = i.Quantity
-
-			return temp, err
+	if !found {
+		return temp, &NotFoundError{Kind: "ingredient", Name: name}
+	}
+
+	return temp, nil
+}
+
+// readByName decodes the first document in the collection with name in the field into v. Firestore indexes every
+// field by itself, so only the matching document is read
+func (db *FirestoreDatabase) readByName(collection string, field string, name string, v interface{}) (bool, error) {
+	iter := db.Client.Collection(collection).Where(field, "==", name).Limit(1).Documents(db.Ctx)
+	defer iter.Stop()
+
+	doc, err := iter.Next()
+	if err == iterator.Done {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, doc.DataTo(v)
+}
+
+// readPage returns the documents of a page of the collection ordered by ID, and the cursor of the next page
+func (db *FirestoreDatabase) readPage(collection string, cursor string, limit int) ([]*firestore.DocumentSnapshot, string, error) {
+	query := db.Client.Collection(collection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(limit + 1)
+	if cursor != "" {
+		query = query.StartAfter(cursor)
+	}
+
+	docs, err := query.Documents(db.Ctx).GetAll()
+	if err != nil {
+		return docs, "", err
+	}
+
+	if len(docs) <= limit { // the one extra document is only read to know if there is a next page
+		return docs, "", nil
+	}
+
+	docs = docs[:limit]
+
+	return docs, docs[limit-1].Ref.ID, nil
+}
+
+// ReadRecipesPage reads a page of recipes ordered by ID, see Store
+func (db *FirestoreDatabase) ReadRecipesPage(cursor string, limit int) ([]Recipe, string, error) {
+	temprecipes := []Recipe{}
+
+	docs, next, err := db.readPage(RecipeCollection, cursor, limit)
+	if err != nil {
+		return temprecipes, "", err
+	}
+
+	for _, doc := range docs {
+		recipe := Recipe{}
+
+		err = doc.DataTo(&recipe)
+		if err != nil {
+			return temprecipes, "", err
 		}
+
+		temprecipes = append(temprecipes, recipe)
+	}
+
+	return temprecipes, next, nil
+}
+
+// ReadIngredientsPage reads a page of ingredients ordered by ID, see Store
+func (db *FirestoreDatabase) ReadIngredientsPage(cursor string, limit int) ([]Ingredient, string, error) {
+	tempingredients := []Ingredient{}
+
+	docs, next, err := db.readPage(IngredientCollection, cursor, limit)
+	if err != nil {
+		return tempingredients, "", err
 	}
 
-	err = errors.New("No ingredient named " + name + " in database")
+	for _, doc := range docs {
+		ingredient := Ingredient{}
+
+		err = doc.DataTo(&ingredient)
+		if err != nil {
+			return tempingredients, "", err
+		}
 
-	return temp, err
+		tempingredients = append(tempingredients, ingredient)
+	}
+
+	return tempingredients, next, nil
 }
 
-// DBReadAllRecipes reads all recipes from database
-func DBReadAllRecipes(w http.ResponseWriter) ([]Recipe, error) {
+// ReadAllRecipes reads all recipes from firestore
+func (db *FirestoreDatabase) ReadAllRecipes() ([]Recipe, error) {
 	var temprecipes []Recipe //  Slice of all recipes, iterate over these
 
-	iter := fireBaseDB.Client.Collection(RecipeCollection).Documents(fireBaseDB.Ctx)
+	iter := db.Client.Collection(RecipeCollection).Documents(db.Ctx)
 
 	for {
 		recipe := Recipe{} //  Create a placeholder for the document
@@ -183,12 +315,12 @@ func DBReadAllRecipes(w http.ResponseWriter) ([]Recipe, error) {
 	return temprecipes, nil
 }
 
-// DBReadAllIngredients reads all ingredients from database
-func DBReadAllIngredients(w http.ResponseWriter) ([]Ingredient, error) {
+// ReadAllIngredients reads all ingredients from firestore
+func (db *FirestoreDatabase) ReadAllIngredients() ([]Ingredient, error) {
 	var tempingredients []Ingredient
 
 	//  Collects the entire collection
-	iter := fireBaseDB.Client.Collection(IngredientCollection).Documents(fireBaseDB.Ctx)
+	iter := db.Client.Collection(IngredientCollection).Documents(db.Ctx)
 
 	for {
 		ingredient := Ingredient{} // Creates a struct for each document
@@ -213,13 +345,13 @@ func DBReadAllIngredients(w http.ResponseWriter) ([]Ingredient, error) {
 	return tempingredients, nil
 }
 
-// DBReadAllWebhooks returns all registered webhooks in the database
-func DBReadAllWebhooks(w http.ResponseWriter) ([]Webhook, error) {
+// ReadAllWebhooks returns all registered webhooks in firestore
+func (db *FirestoreDatabase) ReadAllWebhooks() ([]Webhook, error) {
 	var tempWebhooks []Webhook
 
 	Wh := Webhook{}
 
-	iter := fireBaseDB.Client.Collection(WebhooksCollection).Documents(fireBaseDB.Ctx)
+	iter := db.Client.Collection(WebhooksCollection).Documents(db.Ctx)
 
 	for {
 		doc, err := iter.Next()
@@ -242,26 +374,9 @@ func DBReadAllWebhooks(w http.ResponseWriter) ([]Webhook, error) {
 	return tempWebhooks, nil
 }
 
-// DBCheckAuthorization func is used in the register functions
-// to see if the user has authorization to upload data to the DB
-// The authorization token is one which we the creators of the program has created and saved manually
-// For security purposes we have chosen not to include code which saves the token, and the token itself can be given
-// to the reviewers of this project by mail which can be found in the readme
-func DBCheckAuthorization(w http.ResponseWriter, r *http.Request) (bool, []byte, error) {
-	tempToken := Token{}                //  Loop through collection of authorization tokens
-	resp, err := ioutil.ReadAll(r.Body) //  Read the body of the json posted with the authentication token
-
-	if err != nil {
-		return false, resp, errors.Wrap(err, "Couldn't read request: "+err.Error())
-	}
-
-	err = json.Unmarshal(resp, &tempToken)
-	if err != nil {
-		return false, resp, errors.Wrap(err, "Unable to unmarshal request body: "+err.Error())
-	}
-
-	//  Loop through the collection of documents containing approved tokens
-	iter := fireBaseDB.Client.Collection(TokenCollection).Documents(fireBaseDB.Ctx)
+// CheckToken loops through the collection of approved tokens and returns true if token is one of them
+func (db *FirestoreDatabase) CheckToken(token string) (bool, error) {
+	iter := db.Client.Collection(TokenCollection).Documents(db.Ctx)
 
 	for {
 		DBToken := Token{}
@@ -272,19 +387,19 @@ func DBCheckAuthorization(w http.ResponseWriter, r *http.Request) (bool, []byte,
 		}
 
 		if err != nil {
-			return false, resp, errors.Wrap(err, "Couldn't iterate over document collection: "+err.Error())
+			return false, errors.Wrap(err, "Couldn't iterate over document collection: "+err.Error())
 		}
 
 		err = doc.DataTo(&DBToken) // put data into temp struct
 		if err != nil {
-			return false, resp, errors.Wrap(err, "Couldn't retrieve document from collection: "+err.Error())
+			return false, errors.Wrap(err, "Couldn't retrieve document from collection: "+err.Error())
 		}
 
 		//  If the token the user posted is in the collection, return true
-		if tempToken.AuthToken == DBToken.AuthToken {
-			return true, resp, err
+		if token == DBToken.AuthToken {
+			return true, nil
 		}
 	}
 
-	return false, resp, errors.Wrap(err, "Failed to find valid token")
+	return false, nil
 }
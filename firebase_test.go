@@ -186,5 +186,22 @@ func TestDBCheckAuthorization(t *testing.T) {
 		t.Error(err)
 	}
 
+	// An unknown token is not an error, so the handlers answer 401 like before the Store interface.
+	// The old firestore loop ended with errors.Wrap of a nil error, which is nil
+	request, _ = json.Marshal(Token{AuthToken: "not a token"})
+	r = httptest.NewRequest(http.MethodPost, "/cravings/food/ingredient", bytes.NewReader(request))
+
+	testBool, _, err = DBCheckAuthorization(w, r)
+	if testBool || err != nil {
+		t.Error("expected unknown token to be unauthorised without error, got", testBool, err)
+	}
+
+	w = httptest.NewRecorder()
+	HandlerFood(w, httptest.NewRequest(http.MethodPost, "/cravings/food/ingredient", bytes.NewReader(request)))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Error("expected 401 for unknown token, got", w.Code)
+	}
+
 	fmt.Println("test DBCheckAuthorization")
 }
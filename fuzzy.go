@@ -0,0 +1,198 @@
+package cravings
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxSuggestions is the most did-you-mean suggestions given when a name is not found
+const MaxSuggestions = 3
+
+// NotFoundError is returned when there is no ingredient or recipe with a name, with the names that are close to it
+type NotFoundError struct {
+	Kind        string // "ingredient" or "recipe"
+	Name        string
+	Suggestions []string
+}
+
+// Error says what was not found, and suggests the closest names
+func (e *NotFoundError) Error() string {
+	message := "No " + e.Kind + " named " + e.Name + " in database"
+
+	if len(e.Suggestions) > 0 {
+		message += ". Did you mean: " + strings.Join(e.Suggestions, ", ") + "?"
+	}
+
+	return message
+}
+
+// EditDistance returns the number of letters that have to be added, removed or changed to turn a into b
+func EditDistance(a string, b string) int {
+	first, second := []rune(a), []rune(b)
+
+	previous := make([]int, len(second)+1) // distances from the previous letter of first to every prefix of second
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(first); i++ {
+		current := make([]int, len(second)+1)
+		current[0] = i
+
+		for j := 1; j <= len(second); j++ {
+			change := 1
+			if first[i-1] == second[j-1] {
+				change = 0
+			}
+
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+change))
+		}
+
+		previous = current
+	}
+
+	return previous[len(second)]
+}
+
+// minInt returns the smallest of two ints
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// maxTypos returns how many typos a name of the given length can have and still be suggested
+func maxTypos(length int) int {
+	switch {
+	case length <= 4:
+		return 1
+	case length <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Suggest returns the names closest to name, at most max of them, compared after normalising them.
+// Names with too many typos to be what was meant are not suggested
+func Suggest(name string, names []string, max int) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	var suggestions []suggestion
+
+	normalized := NormalizeName(name)
+	seen := map[string]bool{}
+
+	for _, candidate := range names {
+		if seen[candidate] {
+			continue
+		}
+
+		seen[candidate] = true
+
+		distance := EditDistance(normalized, NormalizeName(candidate))
+		if distance <= maxTypos(len([]rune(normalized))) {
+			suggestions = append(suggestions, suggestion{name: candidate, distance: distance})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+
+		return suggestions[i].name < suggestions[j].name
+	})
+
+	var closest []string
+
+	for i := 0; i < len(suggestions) && i < max; i++ {
+		closest = append(closest, suggestions[i].name)
+	}
+
+	return closest
+}
+
+// SuggestIngredients returns the names of the ingredients closest to name, an ingredient is suggested by
+// its name if one of its aliases is close
+func SuggestIngredients(name string, ingredients []Ingredient, max int) []string {
+	var names []string
+
+	all := ingredientNames(ingredients)
+
+	for _, suggested := range Suggest(name, all, len(all)) {
+		ing, _ := FindIngredient(suggested, ingredients)
+		if !containsString(names, ing.Name) {
+			names = append(names, ing.Name)
+		}
+
+		if len(names) == max {
+			break
+		}
+	}
+
+	return names
+}
+
+// ingredientNames returns the names and aliases of the ingredients
+func ingredientNames(ingredients []Ingredient) []string {
+	var names []string
+
+	for _, ing := range ingredients {
+		names = append(names, ing.Name)
+		names = append(names, ing.Aliases...)
+	}
+
+	return names
+}
+
+// containsString returns true if s is in list
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DBFindRecipe reads the recipe with the given name from the database, names are compared like
+// ingredient names. If it is not found the error is a *NotFoundError with the closest recipe names
+func DBFindRecipe(name string, w http.ResponseWriter) (Recipe, error) {
+	rec, err := DBReadRecipeByName(name, w)
+	if err == nil {
+		return rec, nil
+	}
+
+	recipes, err := DBReadAllRecipes(w)
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	var names []string
+
+	for _, rec := range recipes {
+		if NormalizeName(rec.RecipeName) == NormalizeName(name) {
+			return rec, nil
+		}
+
+		names = append(names, rec.RecipeName)
+	}
+
+	return Recipe{}, &NotFoundError{Kind: "recipe", Name: name, Suggestions: Suggest(name, names, MaxSuggestions)}
+}
+
+// notFound returns the *NotFoundError and true if err is caused by a name that is not in the database
+func notFound(err error) (*NotFoundError, bool) {
+	e, ok := errors.Cause(err).(*NotFoundError)
+	return e, ok
+}
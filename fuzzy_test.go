@@ -0,0 +1,67 @@
+package cravings
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, c := range []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"tomato", "tomato", 0},
+		{"tomatto", "tomato", 1},
+		{"tomtao", "tomato", 2},
+		{"", "milk", 4},
+		{"kitten", "sitting", 3},
+	} {
+		if distance := EditDistance(c.a, c.b); distance != c.expected {
+			t.Error("expected distance", c.expected, "between "+c.a+" and "+c.b+", got", distance)
+		}
+	}
+
+	fmt.Println("testing EditDistance")
+}
+
+func TestSuggestIngredients(t *testing.T) {
+	ingredients := []Ingredient{
+		{Name: "tomato"},
+		{Name: "potato"},
+		{Name: "zucchini", Aliases: []string{"courgette"}},
+		{Name: "milk"},
+	}
+
+	suggestions := SuggestIngredients("tomatos", ingredients, MaxSuggestions) // plural with a typo
+	if len(suggestions) != 2 || suggestions[0] != "tomato" || suggestions[1] != "potato" {
+		t.Error("expected tomato and potato, got", suggestions)
+	}
+
+	suggestions = SuggestIngredients("corgette", ingredients, MaxSuggestions) // typo in alias
+	if len(suggestions) != 1 || suggestions[0] != "zucchini" {
+		t.Error("expected zucchini, got", suggestions)
+	}
+
+	suggestions = SuggestIngredients("flour", ingredients, MaxSuggestions)
+	if len(suggestions) != 0 {
+		t.Error("expected no suggestions, got", suggestions)
+	}
+}
+
+func TestDBFindIngredientSuggestions(t *testing.T) {
+	ing := Ingredient{Name: "testcinnamon", Unit: "g", Quantity: 1}
+
+	err := DBSaveIngredient(&ing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBDelete(ing.ID, IngredientCollection, nil)
+
+	_, err = DBFindIngredient("testcinamon", nil)
+
+	e, ok := notFound(err)
+	if !ok || len(e.Suggestions) == 0 || e.Suggestions[0] != "testcinnamon" {
+		t.Error("expected testcinnamon to be suggested, got", err)
+	}
+}
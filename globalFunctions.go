@@ -2,7 +2,6 @@ package cravings
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -40,6 +39,19 @@ func QueryGet(s string, def string, r *http.Request) string {
 	return query
 }
 
+// QueryUnits reads the unit system quantities are displayed in from the query "units",
+// empty if it is not set
+func QueryUnits(r *http.Request) (string, error) {
+	system := strings.ToLower(r.URL.Query().Get("units"))
+
+	switch system {
+	case "", UnitsMetric, UnitsImperial, UnitsOriginal:
+		return system, nil
+	}
+
+	return "", errors.New("units has to be " + UnitsMetric + ", " + UnitsImperial + " or " + UnitsOriginal)
+}
+
 // CallURL post webhooks to webhooks.site
 func CallURL(event string, s interface{}, w http.ResponseWriter) error {
 	webhooks, err := DBReadAllWebhooks(w) // gets all webhooks
@@ -60,7 +72,8 @@ func CallURL(event string, s interface{}, w http.ResponseWriter) error {
 
 			fmt.Println("Attempting invocation of URL " + webhooks[i].URL + "...")
 
-			resp, err := http.Post(webhooks[i].URL, "json", bytes.NewReader(requestBody)) // post webhook to webhooks.site
+			// post webhook to webhooks.site, with timeout and circuit breaker for the webhook
+			resp, err := PostWebhook(webhooks[i], requestBody)
 			if err != nil {
 				fmt.Fprintln(w, "Error in HTTP request: "+err.Error(), http.StatusBadRequest)
 				return err
@@ -73,60 +86,87 @@ func CallURL(event string, s interface{}, w http.ResponseWriter) error {
 	return nil
 }
 
-// ReadIngredients splits up the ingredient name from the quantity from the URL
+// ReadIngredients splits up the ingredient name from the quantity from the URL, in the old syntax 'name|quantity|unit'.
+// Ingredients without '|' are read as a line of text, i.e. "2 1/2 cups chopped tomatoes".
+// Every ingredient that can not be read is in the returned ItemErrors
 func ReadIngredients(ingredients []string, w http.ResponseWriter) ([]Ingredient, error) {
 	IngredientList := []Ingredient{}
-	defVal := 1.0 //default value for quantity if not set
 
-	var err error
+	var errs ItemErrors
 
 	for i := range ingredients {
-		ingredient := strings.Split(ingredients[i], "|") //splits up the string 'name|quantity|unit'
-		ingredientTemp := Ingredient{}
-
-		if len(ingredient) < 3 { //if quantity value is set
-			return IngredientList, errors.New(
-				"Failed to read ingredient list. ?ingredients={name|quantity|unit}_{...}")
+		ingredientTemp, err := readIngredient(ingredients[i])
+		if err != nil {
+			errs = append(errs, ItemError{Item: i + 1, Input: ingredients[i], Err: err})
+			continue
 		}
 
-		ingredientTemp.Name = ingredient[0] //name of the ingredient
+		IngredientList = append(IngredientList, ingredientTemp)
+	}
 
-		allowed := false
+	if len(errs) > 0 {
+		return IngredientList, errs
+	}
 
-		for _, unit := range AllowedUnit {
-			if ingredient[2] == unit {
-				allowed = true
-			}
-		}
+	return IngredientList, nil
+}
 
-		if !allowed {
-			return IngredientList, errors.New(ingredient[2] + " is not an allowed unit.")
-		}
+// readIngredient reads one ingredient in the syntax 'name|quantity|unit', or as a line of text
+func readIngredient(s string) (Ingredient, error) {
+	if !strings.Contains(s, "|") {
+		return ParseIngredientLine(s)
+	}
 
-		ingredientTemp.Unit = ingredient[2]                                  //sets the unit
-		ingredientTemp.Quantity, err = strconv.ParseFloat(ingredient[1], 64) //sets the quantity
+	ingredient := strings.Split(s, "|") //splits up the string 'name|quantity|unit', anything after the unit is ignored
+	if len(ingredient) < 3 {
+		return Ingredient{}, errors.New("Failed to read ingredient, has to be name|quantity|unit")
+	}
 
-		if err != nil { //if error: set quantity to defVal
-			ingredientTemp.Quantity = defVal
-		}
+	quantity, err := strconv.ParseFloat(ingredient[1], 64)
+	if err != nil {
+		return Ingredient{}, errors.New("Quantity \"" + ingredient[1] + "\" is not a number")
+	}
 
-		IngredientList = append(IngredientList, ingredientTemp)
+	ing := Ingredient{Name: ingredient[0], Quantity: quantity, Unit: ingredient[2]}
+
+	return ing, CheckIngredient(ing)
+}
+
+// CheckIngredient returns an error if the ingredient has no name, an unknown unit or a quantity that is not positive
+func CheckIngredient(ing Ingredient) error {
+	if strings.TrimSpace(ing.Name) == "" {
+		return errors.New("No ingredient name")
 	}
 
-	return IngredientList, nil
+	_, err := LookupUnit(ing.Unit) //checks the unit registry
+	if err != nil {
+		return err
+	}
+
+	if ing.Quantity <= 0 {
+		return errors.New("Quantity has to be more than 0")
+	}
+
+	return nil
 }
 
 // CalcRemaining calculates the nutritional value from one ingredient to another.
-// If subtract is true, it also subtracts quantity from rec in ing
-func CalcRemaining(ing Ingredient, rec Ingredient, subtract bool) Ingredient {
+// If subtract is true, it also subtracts quantity from rec in ing.
+// Returns an error if the units of the two ingredients can not be converted to each other
+func CalcRemaining(ing Ingredient, rec Ingredient, subtract bool) (Ingredient, error) {
 	if ing.Unit != rec.Unit { //if the ingredients measures in different units
-		if strings.Contains(rec.Unit, "spoon") { //if rec contains spoon unit
-			noOfSpoons := ing.Calories / (rec.Calories / rec.Quantity) //calculates number of spoons for ing
-			unitPerSpoon := ing.Quantity / noOfSpoons                  //how many calories in one spoon
-			rec.Quantity *= unitPerSpoon                               //spoons times with calories per
-			rec.Unit = ing.Unit                                        //spoon to get the same unit
-		} else {
-			ConvertUnit(&ing, rec.Unit) //convert ing to same unit as rec
+		// use the measures of rec if ing does not know them, it is the same ingredient
+		if ing.Density == 0 {
+			ing.Density = rec.Density
+		}
+
+		if ing.PieceWeight == 0 {
+			ing.PieceWeight = rec.PieceWeight
+		}
+
+		err := ConvertUnit(&ing, rec.Unit) //convert ing to same unit as rec
+		if err != nil {
+			return ing, err
 		}
 	}
 
@@ -145,49 +185,52 @@ func CalcRemaining(ing Ingredient, rec Ingredient, subtract bool) Ingredient {
 	ing.Nutrients.Protein.Quantity = (rec.Nutrients.Protein.Quantity / rec.Quantity) * ing.Quantity
 	ing.Nutrients.Sugar.Quantity = (rec.Nutrients.Sugar.Quantity / rec.Quantity) * ing.Quantity
 
-	return ing
+	return ing, nil
 }
 
 // CalcNutrition calculates nutritional info for given ingredient
 func CalcNutrition(ing Ingredient, w http.ResponseWriter) (Ingredient, error) {
-	temping, err := DBReadIngredientByName(ing.Name, w) //gets the ingredient with the same name from firebase
+	temping, err := DBFindIngredient(ing.Name, w) //gets the ingredient with the same name or alias from firebase
 	if err != nil {
 		return ing, errors.Wrap(err, "Could not read ingredient by name "+err.Error())
 	}
 
-	ing.ID = temping.ID               // add ID to ing since it's a copy
-	ing.Nutrients = temping.Nutrients // reset nutrients to nutrients for 1g or 1l
-
-	switch ing.Unit {
-	case "kg":
-		ConvertUnit(&ing, "g")
-	case "g":
-		ConvertUnit(&ing, "g")
-	case "l":
-		ConvertUnit(&ing, "l")
-	case "dl":
-		ConvertUnit(&ing, "l")
-	case "cl":
-		ConvertUnit(&ing, "l")
-	case "ml":
-		ConvertUnit(&ing, "l")
-	case "pc":
-	case "tablespoon":
-		err := GetNutrients(&ing, w)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+	ing.ID = temping.ID     // add ID to ing since it's a copy
+	ing.Name = temping.Name // use the name in the database, not a plural or alias
+
+	// density and piece weight from the database, for converting between weight, volume and pieces
+	if ing.Density == 0 {
+		ing.Density = temping.Density
+	}
+
+	if ing.PieceWeight == 0 {
+		ing.PieceWeight = temping.PieceWeight
+	}
+
+	perUnit := temping // nutrients for 1 of the unit, 1 g or 1 l from the database
+
+	err = ConvertUnit(&ing, temping.Unit) // convert to the unit the ingredient is saved with
+	if err != nil {
+		// The measures needed to convert are not known, i.e. tablespoons of an ingredient saved in g
+		// without a density, so get the nutrients for 1 of the unit instead
+		perUnit.Unit = ing.Unit
+		perUnit.Quantity = 1
+
+		nutrientErr := GetNutrients(&perUnit, w)
+		if nutrientErr != nil {
+			return ing, errors.Wrap(nutrientErr, "Could not get nutrients for "+ing.Unit+" of "+ing.Name)
 		}
-	case "teaspoon":
-		err := GetNutrients(&ing, w)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+
+		if perUnit.Weight <= 0 { // the weight is needed to know how much of the ingredient there is
+			return ing, errors.Wrap(err, ing.Name+" is saved in "+temping.Unit)
 		}
-	default:
-		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		learnMeasures(&ing, perUnit.Weight)
 	}
 
-	ing.Calories = temping.Calories * ing.Quantity //calculates calories based on ingredients quantity
-	ing.Weight = temping.Weight * ing.Quantity     //calculates weight based on ingredients quantity
+	ing.Nutrients = perUnit.Nutrients              // reset nutrients to nutrients for 1 unit
+	ing.Calories = perUnit.Calories * ing.Quantity //calculates calories based on ingredients quantity
+	ing.Weight = perUnit.Weight * ing.Quantity     //calculates weight based on ingredients quantity
 
 	// Calc nutrition :
 	ing.Nutrients.Carbohydrate.Quantity *= ing.Quantity
@@ -199,69 +242,40 @@ func CalcNutrition(ing Ingredient, w http.ResponseWriter) (Ingredient, error) {
 	return ing, nil
 }
 
-// ConvertUnit converts units for ingredients, and changes their quantity respectively.
-func ConvertUnit(ing *Ingredient, unitConvertTo string) {
-	if ing.Unit == "kg" && unitConvertTo == "g" {
-		ing.Quantity *= 1000
-		ing.Unit = unitConvertTo
+// learnMeasures sets the density or piece weight of the ingredient from the weight in g of 1 of its unit,
+// so it can be converted to weight later on
+func learnMeasures(ing *Ingredient, weight float64) {
+	unit, err := LookupUnit(ing.Unit)
+	if err != nil {
+		return
 	}
 
-	if ing.Unit == "g" && unitConvertTo == "kg" {
-		ing.Quantity /= 1000
-		ing.Unit = unitConvertTo
+	switch unit.Dimension {
+	case DimensionVolume:
+		ing.Density = weight / unit.Factor // g per ml
+	case DimensionCount:
+		ing.PieceWeight = weight / unit.Factor
 	}
+}
 
-	if unitConvertTo == "l" {
-		switch ing.Unit {
-		case "dl":
-			ing.Quantity /= 10
-		case "cl":
-			ing.Quantity /= 100
-		case "ml":
-			ing.Quantity /= 1000
-		}
-
-		ing.Unit = unitConvertTo
-	}
-
-	if unitConvertTo == "dl" {
-		switch ing.Unit {
-		case "l":
-			ing.Quantity *= 10
-		case "cl":
-			ing.Quantity /= 10
-		case "ml":
-			ing.Quantity /= 100
-		}
-
-		ing.Unit = unitConvertTo
+// ConvertUnit converts units for ingredients, and changes their quantity respectively.
+// Weight, volume and pieces are converted between if the ingredient has a density or piece weight.
+// The ingredient is not changed if the units can not be converted
+func ConvertUnit(ing *Ingredient, unitConvertTo string) error {
+	quantity, err := ConvertWith(ing.Quantity, ing.Unit, unitConvertTo, ing.Measures())
+	if err != nil {
+		return err
 	}
 
-	if unitConvertTo == "cl" {
-		switch ing.Unit {
-		case "dl":
-			ing.Quantity *= 10
-		case "l":
-			ing.Quantity *= 100
-		case "ml":
-			ing.Quantity /= 10
-		}
-
-		ing.Unit = unitConvertTo
-	}
+	ing.Quantity = quantity
+	ing.Unit = unitConvertTo
 
-	if unitConvertTo == "ml" {
-		switch ing.Unit {
-		case "cl":
-			ing.Quantity *= 10
-		case "dl":
-			ing.Quantity *= 100
-		case "l":
-			ing.Quantity *= 1000
-		}
+	return nil
+}
 
-		ing.Unit = unitConvertTo
-	}
+// Measures returns the density and piece weight of the ingredient
+func (ing Ingredient) Measures() Measures {
+	return Measures{Density: ing.Density, PieceWeight: ing.PieceWeight}
 }
 
 // InitAPICredentials func opens up local file and reads the application id and key from that file
@@ -290,26 +304,14 @@ func InitAPICredentials() error {
 
 // UnitCheck func checks the unit measurements of two ingredients and checks if they are of the same type solid/liquid
 func UnitCheck(firstIngredient string, secondIngredient string) bool {
-	if strings.Contains(firstIngredient, "l") {
-		if strings.Contains(secondIngredient, "l") {
-			return true
-		}
-	}
-
-	if strings.Contains(firstIngredient, "g") {
-		if strings.Contains(secondIngredient, "g") {
-			return true
-		}
-	}
-
-	if strings.Contains(firstIngredient, "spoon") {
-		// table/teaspoon can be registered as liquid or solid
-		return true
+	first, err := LookupUnit(firstIngredient)
+	if err != nil {
+		return false
 	}
 
-	if strings.Contains(firstIngredient, "pc") {
+	if first.Spoon { // table/teaspoon can be registered as liquid or solid
 		return true
 	}
 
-	return false
+	return SameDimension(firstIngredient, secondIngredient)
 }
@@ -2,6 +2,7 @@ package cravings
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -48,6 +49,22 @@ func TestQueryGet(t *testing.T) {
 	fmt.Println("TestQueryGet")
 }
 
+func TestQueryUnits(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/cravings/meal/?units=Imperial", nil)
+
+	units, err := QueryUnits(r)
+	if err != nil || units != UnitsImperial {
+		t.Error("expected imperial, got", units, err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/cravings/meal/?units=cubits", nil)
+
+	_, err = QueryUnits(r)
+	if err == nil {
+		t.Error("accepted unknown unit system")
+	}
+}
+
 func TestCallURL(t *testing.T) {
 	w := httptest.NewRecorder()                     // creates ResponsRecoder
 	TestRecipe := Recipe{RecipeName: "TestCallURl"} // create a struct with a name
@@ -83,6 +100,30 @@ func TestReadIngredients(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
+
+	test, err = ReadIngredients([]string{"cheese|3|kg", "2 1/2 dl milk"}, w) // test mixing syntax and lines
+	if err != nil || len(test) != 2 || test[1].Name != "milk" || test[1].Quantity != 2.5 || test[1].Unit != "dl" {
+		t.Error("could not read ingredient line", test, err)
+	}
+
+	test, err = ReadIngredients([]string{"cheese|3|kg|grated"}, w) // fields after the unit are ignored
+	if err != nil || len(test) != 1 || test[0].Unit != "kg" {
+		t.Error("could not read ingredient with extra fields", test, err)
+	}
+}
+
+func TestReadIngredientsFail(t *testing.T) {
+	// missing quantity, quantity that is not a number and unknown unit, errors are supposed to be sent
+	_, err := ReadIngredients([]string{"cheese||kg", "milk|2|l", "flour|much|g", "salt|1|bucket"}, nil)
+
+	errs, ok := err.(ItemErrors)
+	if !ok || len(errs) != 3 {
+		t.Fatal("expected 3 item errors, got", err)
+	}
+
+	if errs[0].Item != 1 || errs[1].Item != 3 || errs[2].Item != 4 {
+		t.Error("wrong items in errors", errs)
+	}
 }
 
 func TestConvertUnit(t *testing.T) {
@@ -208,6 +249,69 @@ func TestConvertUnit(t *testing.T) {
 	}
 }
 
+func TestConvertUnitFail(t *testing.T) {
+	testIngredient := Ingredient{Name: "TestIngredient", Quantity: 2, Unit: "kg"}
+
+	err := ConvertUnit(&testIngredient, "l") // test convert from kg to l, error is supposed to be sent
+	if err == nil {
+		t.Error("converted kg to l")
+	}
+
+	if testIngredient.Quantity != 2 || testIngredient.Unit != "kg" { // ingredient should not be changed
+		t.Error("ingredient was changed by failed conversion: ", testIngredient)
+	}
+}
+
+func TestConvertUnitDensity(t *testing.T) {
+	testIngredient := Ingredient{Name: "flour", Quantity: 3, Unit: "dl", Density: 0.55}
+
+	err := ConvertUnit(&testIngredient, "g") // test convert from dl to g with the density of flour
+	if err != nil || math.Abs(testIngredient.Quantity-165) > 0.0001 || testIngredient.Unit != "g" {
+		t.Error("expected 165 g of flour, got", testIngredient, err)
+	}
+}
+
+func TestCalcNutritionPieces(t *testing.T) {
+	egg := Ingredient{Name: "testegg", Unit: "g", Quantity: 1, Calories: 1.43, Weight: 1, PieceWeight: 60,
+		Nutrients: NewTotalNutrients(1.43, 0.1, 0.01, 0, 0.13)}
+
+	err := DBSaveIngredient(&egg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBDelete(egg.ID, IngredientCollection, nil)
+
+	ing, err := CalcNutrition(Ingredient{Name: "testegg", Unit: "pc", Quantity: 2}, nil) // 2 pc is 120 g
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ing.Unit != "g" || ing.Quantity != 120 || math.Abs(ing.Calories-171.6) > 0.0001 {
+		t.Error("expected 120 g of egg with 171.6 calories, got", ing)
+	}
+
+	_, err = CalcNutrition(Ingredient{Name: "testegg", Unit: "dl", Quantity: 1}, nil) // egg has no density
+	if err == nil {
+		t.Error("calculated nutrition for dl of an ingredient without a density")
+	}
+}
+
+func TestCalcRemainingSpoons(t *testing.T) {
+	have := Ingredient{Name: "salt", Quantity: 36, Unit: "g"}
+	need := Ingredient{Name: "salt", Quantity: 2, Unit: "tablespoon", Weight: 36, Density: 1.2,
+		Nutrients: NewTotalNutrients(0, 0, 0, 0, 0)}
+
+	// salt has no calories, so spoons can not be calculated from calories
+	ing, err := CalcRemaining(have, need, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ing.Unit != "tablespoon" || math.Abs(ing.Quantity-2) > 0.0001 || ing.Calories != 0 || math.IsNaN(ing.Weight) {
+		t.Error("expected 2 tablespoons of salt without calories, got", ing)
+	}
+}
+
 func TestInitAPICredentials(t *testing.T) {
 	err := InitAPICredentials()
 
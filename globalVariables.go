@@ -10,6 +10,18 @@ var StartTime time.Time // start run time
 // FirestoreCredentials is the credentials file for firestore db
 const FirestoreCredentials = "./cloudproject-2a9c2-firebase-adminsdk-0om9b-bca5ed564a.json"
 
+// DatabaseFirestore is the name used to select the firestore database at startup
+const DatabaseFirestore = "firestore"
+
+// DatabaseMemory is the name used to select the in-memory database at startup
+const DatabaseMemory = "memory"
+
+// DatabaseBolt is the name used to select the on-disk bolt database at startup
+const DatabaseBolt = "bolt"
+
+// BoltFile is the path to the bolt database file
+var BoltFile = "./cravings.db"
+
 // RecipeCollection is the name of the recipes collection in the database
 const RecipeCollection = "recipes"
 
@@ -22,12 +34,78 @@ const TokenCollection = "tokens"
 // WebhooksCollection is the name of the webhooks collection in the database
 const WebhooksCollection = "webhooks"
 
+// RecipeNameIndex is the name of the bucket indexing recipe names to ids in the bolt database
+const RecipeNameIndex = "recipes_by_name"
+
+// IngredientNameIndex is the name of the bucket indexing ingredient names to ids in the bolt database
+const IngredientNameIndex = "ingredients_by_name"
+
+// RecipeNameField is the field recipe names are stored in by firestore, which uses the names of the struct fields
+const RecipeNameField = "RecipeName"
+
+// IngredientNameField is the field ingredient names are stored in by firestore
+const IngredientNameField = "Name"
+
 // AllowedUnit = list of units of measurement: kilogram, gram, liter, deciliter, mililiter, piece, teaspoon etc.
-var AllowedUnit = [8]string{"kg", "g", "l", "dl", "ml", "pc", "tablespoon", "teaspoon"}
+// The units and their conversions are in the unit registry in units.go
+var AllowedUnit = unitNames()
 
 // URLRegistration is the url to edamam api for getting nutrition details when registering an ingredient or recipe
 var URLRegistration = "https://api.edamam.com/api/nutrition-details"
 
+// URLNutritionData is the url to edamam api for getting nutrition data for a single ingredient
+var URLNutritionData = "https://api.edamam.com/api/nutrition-data"
+
+// EdamamCallsPerMinute is how many calls can be made to Edamam each minute
+var EdamamCallsPerMinute = 10
+
+// EdamamCallsPerDay is how many calls can be made to Edamam each day
+var EdamamCallsPerDay = 1000
+
+// EdamamRetries is how many times a call to Edamam is retried after a 429 or 5xx response
+var EdamamRetries = 3
+
+// EdamamBackoff is the wait before the first retry, doubled for each retry after that
+var EdamamBackoff = 500 * time.Millisecond
+
+// EdamamMaxWait is the longest wait before a retry, if Edamam asks for longer the call fails right away
+var EdamamMaxWait = 10 * time.Second
+
+// Names of the destinations of outbound http calls
+const (
+	OutboundEdamam   = "edamam"
+	OutboundFirebase = "firebase"
+	OutboundWebhook  = "webhook"
+)
+
+// OutboundTimeouts is the timeout for calls to each destination
+var OutboundTimeouts = map[string]time.Duration{
+	OutboundEdamam:   10 * time.Second,
+	OutboundFirebase: 5 * time.Second,
+	OutboundWebhook:  5 * time.Second,
+}
+
+// BreakerFailures is how many failed calls in a row opens the circuit breaker of a destination
+var BreakerFailures = 5
+
+// BreakerCooldown is how long a circuit breaker stays open before a new call is tried
+var BreakerCooldown = 30 * time.Second
+
+// NutritionEdamam is the name used to select Edamam as nutrition provider at startup
+const NutritionEdamam = "edamam"
+
+// NutritionLocal is the name used to select the local nutrient table as nutrition provider at startup
+const NutritionLocal = "local"
+
+// NutrientFile is the path to the .json or .csv file with the local nutrient table
+var NutrientFile = "./nutrients.json"
+
+// NutrientCacheFile is the path to the file the nutrient cache is saved to
+var NutrientCacheFile = "./nutrientCache.json"
+
+// NutrientCacheTTL is how long nutrients from the nutrition provider are cached
+var NutrientCacheTTL = 30 * 24 * time.Hour
+
 // AppID is Application ID for external API
 var AppID = ""
 
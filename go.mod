@@ -7,5 +7,6 @@ require (
 	cloud.google.com/go/storage v1.1.2 // indirect
 	firebase.google.com/go v3.10.0+incompatible
 	github.com/pkg/errors v0.8.1
+	go.etcd.io/bbolt v1.3.5
 	google.golang.org/api v0.13.0
 )
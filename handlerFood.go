@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+
+	"github.com/pkg/errors"
 )
 
 const caseing = "ingredient"
 const caserec = "recipe"
+const casesuggest = "suggest"
+const casesearch = "search"
 
 // HandlerFood which registers or view either an ingredient or a recipe
 // Whenever calling this endpoint in the browser, it is only possible to view the food,
@@ -25,22 +30,60 @@ func HandlerFood(w http.ResponseWriter, r *http.Request) {
 		name = parts[4]
 	}
 
+	list := "" // What to list for the ingredient, i.e. "recipes" in /cravings/food/ingredient/{name}/recipes
+	if len(parts) > 5 {
+		list = parts[5]
+	}
+
 	if endpoint == "" {
 		HandlerNil(w, r)
 	}
 
 	switch r.Method {
 	case http.MethodGet: // Gets either recipes or ingredients
+		units, err := QueryUnits(r) // Unit system to show quantities in
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		switch endpoint {
 		case caseing:
 			if name != "" { // If ingredient name is specified in URL
-				ingr, err := DBReadIngredientByName(name, w) // Get that ingredient
+				ingr, err := DBFindIngredient(name, w) // Get that ingredient, by name or alias
 
-				if err != nil {
+				// the name is not in the database, the error suggests the closest names
+				if _, ok := notFound(err); ok {
+					http.Error(w, err.Error(), http.StatusNotFound)
+					return
+				} else if err != nil {
 					http.Error(w, "Couldn't read ingredient by name: "+err.Error(), http.StatusInternalServerError)
 					return
 				}
 
+				if list == caserec+"s" { // The recipes using the ingredient
+					uses, err := recipeIndex.Uses(ingr, w)
+					if err != nil {
+						http.Error(w, "Couldn't retrieve recipes: "+err.Error(), http.StatusInternalServerError)
+						return
+					}
+
+					for i := range uses {
+						if units != "" {
+							uses[i].Quantity, uses[i].Unit = DisplayUnit(uses[i].Quantity, uses[i].Unit, units)
+						}
+					}
+
+					err = json.NewEncoder(w).Encode(&uses)
+					if err != nil {
+						http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusInternalServerError)
+					}
+
+					return
+				}
+
+				ingr.Display(units)
+
 				err = json.NewEncoder(w).Encode(&ingr)
 
 				if err != nil {
@@ -48,12 +91,37 @@ func HandlerFood(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 			} else {
+				options, err := ReadListOptions(r) // How to filter, sort and page the ingredients, if at all
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				if options.Paged {
+					page, next, err := ListIngredients(options, w)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+
+					DisplayIngredients(page, units)
+
+					err = writeListPage(w, r, page, next)
+					if err != nil {
+						http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusInternalServerError)
+					}
+
+					return
+				}
+
 				ingredients, err := DBReadAllIngredients(w) // Else retrieve all ingredients
 				if err != nil {
 					http.Error(w, "Couldn't retrieve ingredients: "+err.Error(), http.StatusBadRequest)
 					return
 				}
 
+				DisplayIngredients(ingredients, units)
+
 				err = json.NewEncoder(w).Encode(&ingredients)
 				if err != nil {
 					http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusInternalServerError)
@@ -64,30 +132,115 @@ func HandlerFood(w http.ResponseWriter, r *http.Request) {
 			if name != "" { // If user wrote in query for name of recipe
 				re := Recipe{}
 
-				re, err := DBReadRecipeByName(name, w) // Get that recipe
-				if err != nil {
+				re, err := DBFindRecipe(name, w) // Get that recipe
+				// the name is not in the database, the error suggests the closest names
+				if _, ok := notFound(err); ok {
+					http.Error(w, err.Error(), http.StatusNotFound)
+					return
+				} else if err != nil {
 					http.Error(w, "Couldn't retrieve recipe: "+err.Error(), http.StatusBadRequest)
 					return
 				}
 
+				DisplayIngredients(re.Ingredients, units)
+
 				err = json.NewEncoder(w).Encode(&re)
 				if err != nil {
 					http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusInternalServerError)
 					return
 				}
 			} else {
+				options, err := ReadListOptions(r) // How to filter, sort and page the recipes, if at all
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				if options.Paged {
+					page, next, err := ListRecipes(options, w)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+
+					for i := range page {
+						DisplayIngredients(page[i].Ingredients, units)
+					}
+
+					err = writeListPage(w, r, page, next)
+					if err != nil {
+						http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusInternalServerError)
+					}
+
+					return
+				}
+
 				recipes, err := DBReadAllRecipes(w) // Else get all recipes
 				if err != nil {
 					http.Error(w, "Couldn't retrieve recipes: "+err.Error(), http.StatusBadRequest)
 					return
 				}
 
+				for i := range recipes {
+					DisplayIngredients(recipes[i].Ingredients, units)
+				}
+
 				err = json.NewEncoder(w).Encode(&recipes)
 				if err != nil {
 					http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusInternalServerError)
 					return
 				}
 			}
+		case casesuggest: // Suggests ingredient and recipe names for what the user has typed
+			q := r.URL.Query().Get("q")
+			if strings.TrimSpace(q) == "" {
+				http.Error(w, "Missing query q, i.e. /cravings/food/suggest?q=tom", http.StatusBadRequest)
+				return
+			}
+
+			limit, err := strconv.Atoi(QueryGet("limit", "10", r)) // reads limit if sent, else set it to 10
+			if err != nil || limit <= 0 {
+				limit = 10
+			}
+
+			suggestions, err := suggestIndex.Suggest(q, limit, w)
+			if err != nil {
+				http.Error(w, "Couldn't retrieve names: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			err = json.NewEncoder(w).Encode(&suggestions)
+			if err != nil {
+				http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		case casesearch: // Searches recipe names, ingredients and descriptions
+			q := r.URL.Query().Get("q")
+			if strings.TrimSpace(q) == "" {
+				http.Error(w, "Missing query q, i.e. /cravings/food/search?q=\"tomato soup\" OR gazpacho", http.StatusBadRequest)
+				return
+			}
+
+			limit, err := strconv.Atoi(QueryGet("limit", "10", r)) // reads limit if sent, else set it to 10
+			if err != nil || limit <= 0 {
+				limit = 10
+			}
+
+			results, err := searchIndex.Search(q, limit, w)
+			if err != nil {
+				http.Error(w, "Couldn't search recipes: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			for i := range results {
+				DisplayIngredients(results[i].Recipe.Ingredients, units)
+			}
+
+			err = json.NewEncoder(w).Encode(&results)
+			if err != nil {
+				http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
 		}
 
 	// Post either recipes or ingredients to firebase DB
@@ -131,7 +284,7 @@ func HandlerFood(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
-				ing, err = DBReadIngredientByName(ing.Name, w) //  Get that ingredient
+				ing, err = DBFindIngredient(ing.Name, w) //  Get that ingredient, by name or alias
 				if err != nil {
 					http.Error(w, "Couldn't retrieve ingredient: "+err.Error(), http.StatusBadRequest)
 					return
@@ -149,6 +302,11 @@ func HandlerFood(w http.ResponseWriter, r *http.Request) {
 						return
 					}
 
+					err = InvalidateNutrients(ing.Name) // forget cached nutrients for the deleted ingredient
+					if err != nil {
+						fmt.Println("Failed to invalidate nutrient cache: " + err.Error())
+					}
+
 					fmt.Fprintln(w, "Successfully deleted ingredient "+ing.Name)
 				} else {
 					http.Error(w, "Can't delete ingredient"+ing.Name+" because it is used in a recipe.", http.StatusForbidden)
@@ -163,7 +321,7 @@ func HandlerFood(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
-				rec, err = DBReadRecipeByName(rec.RecipeName, w) //  Get that recipe
+				rec, err = DBFindRecipe(rec.RecipeName, w) //  Get that recipe
 				if err != nil {
 					http.Error(w, "Couldn't retrieve recipe: "+err.Error(), http.StatusBadRequest)
 					return
@@ -196,32 +354,16 @@ func RegisterIngredient(w http.ResponseWriter, respo []byte) {
 		return
 	}
 
-	ing.Name = strings.ToLower(ing.Name) // force lowercase ingredient name
+	ing.Name = strings.Join(strings.Fields(strings.ToLower(ing.Name)), " ") // force lowercase ingredient name
+	ing.Aliases = NormalizeAliases(ing.Name, ing.Aliases)
 
 	if ing.Unit == "" {
 		http.Error(w, "Could not save ingredient, missing \"unit\"", http.StatusBadRequest)
 		return
 	}
 
-	unitParam := ing.Unit //  Checks if the posted unit is one of the legal measurements
-	inList := false
-
-	for _, v := range AllowedUnit { //  Loops through the allowed units
-		if unitParam == v {
-			inList = true
-			break
-		}
-	} //  If it is one of the allowed units, cast it into g or l
-
-	if inList {
-		if strings.Contains(unitParam, "g") {
-			unitParam = "g"
-		} else if strings.Contains(unitParam, "l") {
-			unitParam = "l"
-		} else {
-			unitParam = "pc"
-		}
-	} else { //  Prints the allowed units for an ingridient
+	unitParam, err := BaseUnit(ing.Unit) //  Checks if the posted unit is one of the legal measurements
+	if err != nil {                      //  Prints the allowed units for an ingridient
 		http.Error(w, "Unit has to be of one of the values ", http.StatusBadRequest)
 		for _, v := range AllowedUnit {
 			fmt.Fprintln(w, v) // Print allowed units
@@ -236,31 +378,47 @@ func RegisterIngredient(w http.ResponseWriter, respo []byte) {
 			err.Error(), http.StatusInternalServerError)
 		return
 	}
-	//  Check to see if the ingredient is already in the DB
-	for i := range allIngredients {
-		if ing.Name == allIngredients[i].Name {
+	//  Check to see if the ingredient, or one of its aliases, is already in the DB
+	for _, name := range append([]string{ing.Name}, ing.Aliases...) {
+		if existing, ok := FindIngredient(name, allIngredients); ok {
 			found = true // found ingredient in database
 
-			http.Error(w, "Ingredient \""+ing.Name+"\" already in database.", http.StatusBadRequest)
+			http.Error(w, "Ingredient \""+name+"\" already in database as \""+existing.Name+"\".",
+				http.StatusBadRequest)
 
 			return
 		}
 	}
 
 	if !found { // if ingredient is not found in database
-		if unitParam != "pc" {
-			ConvertUnit(&ing, unitParam) // convert unit to "g" or "l"
+		err = ConvertUnit(&ing, unitParam) // convert unit to the base unit of its dimension, i.e. "g" or "l"
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
 		ing.Quantity = 1 // force quantity to 1
 
 		err = GetNutrients(&ing, w) // get nutrients for the ingredient
 
-		if err != nil {
+		if errors.Cause(err) == ErrQuotaExhausted {
+			http.Error(w, "Couldn't get nutritional values: "+err.Error(), http.StatusTooManyRequests)
+			return
+		} else if err != nil {
 			http.Error(w, "Couldn't get nutritional values: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		// Edamam gives the weight of 1 l, so the density of liquids is known if it was not posted
+		if ing.Density == 0 && ing.Unit == "l" && ing.Weight > 0 {
+			ing.Density = ing.Weight / 1000
+		}
+
+		// and the weight of 1 piece for ingredients registered in pieces
+		if ing.PieceWeight == 0 && ing.Unit == "pc" && ing.Weight > 0 {
+			ing.PieceWeight = ing.Weight
+		}
+
 		if ing.Nutrients.Energy.Label == "" {
 			// check if it got nutrients from db.
 			//All ingredients will get this label if GetNutrients is ok
@@ -321,11 +479,13 @@ func RegisterRecipe(w http.ResponseWriter, respo []byte) {
 		found := false // Reset if current ingredient is found or not
 
 		for _, j := range allIngredients { // If the ingredient is found the loop breaks and found is set to true
-			if rec.Ingredients[i].Name == j.Name {
+			if j.HasName(rec.Ingredients[i].Name) {
 				found = true
+				rec.Ingredients[i].Name = j.Name // save the recipe with the name in the database, not a plural or alias
 
-				// Check to see if user has posted with the equivalent unit as the ingredient has in the DB
-				if !UnitCheck(rec.Ingredients[i].Unit, j.Unit) {
+				// Check to see if user has posted with the equivalent unit as the ingredient has in the DB,
+				// or a unit that can be converted with the density or piece weight of the ingredient
+				if !UnitCheck(rec.Ingredients[i].Unit, j.Unit) && !CanConvert(rec.Ingredients[i].Unit, j.Unit, j.Measures()) {
 					//  Error message when posting with mismatched units, i.e liquid with kg or solid with ml
 					http.Error(w, "Couldn't save recipe due to unit mismatch: "+
 						rec.Ingredients[i].Name+" has unit "+j.Unit+
@@ -373,51 +533,16 @@ func RegisterRecipe(w http.ResponseWriter, respo []byte) {
 		http.Error(w, "Cannot save recipe, missing ingredient(s) in database:", http.StatusBadRequest)
 
 		for i := range missingingredients {
-			fmt.Fprintln(w, "- "+missingingredients[i]) // print all missing ingredients in http response
+			suggestions := SuggestIngredients(missingingredients[i], allIngredients, MaxSuggestions)
+			if len(suggestions) > 0 { // print all missing ingredients in http response, with the closest names
+				fmt.Fprintln(w, "- "+missingingredients[i]+" (did you mean: "+strings.Join(suggestions, ", ")+"?)")
+			} else {
+				fmt.Fprintln(w, "- "+missingingredients[i])
+			}
 		}
 	}
 }
 
-// GetNutrients gets nutritional info from external API for the ingredient. Returns http error if it fails
-func GetNutrients(ing *Ingredient, w http.ResponseWriter) error {
-	client := http.DefaultClient
-
-	APIURL := "http://api.edamam.com/api/nutrition-data?app_id="
-	APIURL += AppID
-	APIURL += "&app_key="
-	APIURL += AppKey
-	APIURL += "&ingr="
-	// substitute spaces with "%20" so URL to API works with spaces in ingredient name
-	APIURL += strings.ReplaceAll(ing.Name, " ", "%20")
-	if ing.Unit != "pc" {
-		APIURL += "%20"
-		APIURL += ing.Unit
-	} else if ing.Unit == "pc" {
-		APIURL += "%20"
-		APIURL += "piece"
-	}
-
-	resp, err := DoRequest(APIURL, client)
-
-	if err != nil {
-		http.Error(w, "Unable to get "+APIURL+err.Error(), http.StatusBadRequest)
-		return err
-	}
-
-	if resp != nil {
-		defer resp.Body.Close()
-	}
-
-	err = json.NewDecoder(resp.Body).Decode(&ing)
-
-	if err != nil {
-		http.Error(w, "Could not decode response body "+err.Error(), http.StatusInternalServerError)
-		return err
-	}
-
-	return nil
-}
-
 // GetRecipeNutrients calculates total nutritients in a recipe
 func GetRecipeNutrients(rec *Recipe, w http.ResponseWriter) error {
 	// Set all the labels for the recipe
@@ -454,6 +579,10 @@ func GetRecipeNutrients(rec *Recipe, w http.ResponseWriter) error {
 
 		rec.Ingredients[i].Calories = temptotalnutrients.Nutrients.Energy.Quantity
 		rec.Ingredients[i].ID = temptotalnutrients.ID
+
+		// saves the measures with the recipe, so the meal handler can convert between units without the database
+		rec.Ingredients[i].Density = temptotalnutrients.Density
+		rec.Ingredients[i].PieceWeight = temptotalnutrients.PieceWeight
 	}
 
 	return nil
@@ -461,21 +590,11 @@ func GetRecipeNutrients(rec *Recipe, w http.ResponseWriter) error {
 
 // inRecipe is a check to see if an ingredient is present in a recipe
 func inRecipe(ing *Ingredient, w http.ResponseWriter) (bool, error) {
-	//  Get all recipes
-	recipes, err := DBReadAllRecipes(w) // Else get all recipes
-
+	uses, err := recipeIndex.Uses(*ing, w) // recipes using the ingredient, from the index
 	if err != nil {
 		http.Error(w, "Couldn't retrieve recipes: "+err.Error(), http.StatusBadRequest)
 		return false, err
 	}
 
-	for _, r := range recipes {
-		for _, i := range r.Ingredients {
-			if i.Name == ing.Name {
-				return true, err
-			}
-		}
-	}
-
-	return false, err
+	return len(uses) > 0, nil
 }
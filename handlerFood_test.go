@@ -133,7 +133,7 @@ func TestHandlerFood(t *testing.T) {
 	resp = ALLMethodRecipe(Get, "/cravings/food/recipe/Somthing", r, t)
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusBadRequest { // check that test went ok
+	if resp.StatusCode != http.StatusNotFound { // check that test went ok
 		t.Error(resp.StatusCode)
 	}
 
@@ -143,7 +143,7 @@ func TestHandlerFood(t *testing.T) {
 	resp = ALLMethodIngredient(Get, "/cravings/food/ingredient/Somthing", i, t)
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusInternalServerError { // check that test went ok
+	if resp.StatusCode != http.StatusNotFound { // check that test went ok
 		t.Error(resp.StatusCode)
 	}
 
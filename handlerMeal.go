@@ -15,7 +15,11 @@ func HandlerMeal(w http.ResponseWriter, r *http.Request) {
 
 	ingredientsList := []Ingredient{}
 
-	var err error
+	units, err := QueryUnits(r) // unit system to show quantities in
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	switch r.Method { //sets the list of remaining ingredients from either a post or get request
 	case http.MethodPost:
@@ -27,29 +31,23 @@ func HandlerMeal(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			for _, i := range ingredientsList { //checks if unit is an allowed unit
-				allowed := false
+			var errs ItemErrors
 
-				for _, unit := range AllowedUnit {
-					if i.Unit == unit {
-						allowed = true
-					}
-				}
-
-				if !allowed {
-					http.Error(w, i.Unit+" is not an allowed unit.", http.StatusBadRequest)
-					return
+			for n, i := range ingredientsList { //checks the name, unit and quantity of every ingredient
+				err = CheckIngredient(i)
+				if err != nil {
+					errs = append(errs, ItemError{Item: n + 1, Input: i.Name, Err: err})
 				}
+			}
 
-				if i.Quantity <= 0 {
-					http.Error(w, "Not a valid quantity.", http.StatusBadRequest)
-					return
-				}
+			if len(errs) > 0 {
+				http.Error(w, errs.Error(), http.StatusBadRequest)
+				return
 			}
 		}
 	case http.MethodGet:
-		{ //  Case get reads the ingredients which is in the URL query, each ingredient is separated by '_'
-			ingredientsList, err = ReadIngredients(strings.Split(QueryGet("ingredients", "", r), "_"), w)
+		{ //  Case get reads the ingredients which is in the URL query, see ReadMealQuery for the syntax
+			ingredientsList, err = ReadMealQuery(r)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -57,15 +55,20 @@ func HandlerMeal(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	recipeList, err := DBReadAllRecipes(w) //list of all recipes from firebase
-
-	if err != nil {
-		http.Error(w, "Failed to retrieve recipes "+err.Error(), http.StatusInternalServerError)
-		return
-	}
+	autocorrect, _ := strconv.ParseBool(r.URL.Query().Get("autocorrect")) // false if not set or set to non-boolean
+	corrections := []ItemCorrection{}
 
 	for i := range ingredientsList {
 		ingredientsList[i], err = CalcNutrition(ingredientsList[i], w)
+
+		// With autocorrect, a typo with only one ingredient close to it is read as that ingredient
+		if e, ok := notFound(err); ok && autocorrect && len(e.Suggestions) == 1 {
+			corrections = append(corrections, ItemCorrection{Item: i + 1, Input: ingredientsList[i].Name,
+				ReadAs: e.Suggestions[0]})
+			ingredientsList[i].Name = e.Suggestions[0]
+			ingredientsList[i], err = CalcNutrition(ingredientsList[i], w)
+		}
+
 		if err != nil {
 			// Prints to console which ingredient is queried, but is missing in the database.
 			// This will not cause a http error, and the result will just exclude this ingredient.
@@ -73,128 +76,192 @@ func HandlerMeal(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	//  Contains all the recipes the user can make with ingredients at hand,, including the ones which the user
-	//  potentially could make
-	recipeCount := []RecipePrint{}
-
-	for _, list := range recipeList { //Goes through all recipes
-		recipeTemp := RecipePrint{}
-		recipeTemp.RecipeName = list.RecipeName //  Appends the remaining ingredients to a list
-		recipeTemp.Ingredients.Remaining = append(recipeTemp.Ingredients.Remaining, ingredientsList...)
-
-		for _, i := range list.Ingredients { //i is the ingredient needed for the recipe
-			found := false //sets found to true if ingredient is in recipe
-
-			for n, j := range recipeTemp.Ingredients.Remaining { //Name|quantity of ingredients from query
-				if j.Name == i.Name { //if it matches ingredient from recipe
-					found = true       //found ingredient
-					tempUnit := i.Unit //saves the unit the recipe is based on
-
-					j = CalcRemaining(j, i, false) //calculates nutritional value for j
-
-					if strings.Contains(i.Unit, "spoon") { //specialcase: if recipe uses tablespoon or teaspoon as unit
-						noOfSpoons := j.Calories / (i.Calories / i.Quantity) //Amount we have/the value of calories from 1 spoon
-						unitPerSpoon := j.Quantity / noOfSpoons              //calculates the amount of units stored per spoon
-
-						if noOfSpoons <= i.Quantity { // if less or equal to what is needed from recipe
-							tempOriginalUnit := j.Unit
-							j.Unit = i.Unit         //set unit to recipes unit (...spoon)
-							j.Quantity = noOfSpoons //Quantity to number of spoons
-							recipeTemp.Ingredients.Have = append(recipeTemp.Ingredients.Have, j)
-							// Delete the ingredient from remaining:
-							recipeTemp.Ingredients.Remaining =
-								append(recipeTemp.Ingredients.Remaining[:n], recipeTemp.Ingredients.Remaining[n+1:]...)
-							i.Quantity -= j.Quantity //  Calculates the amount the recipe needs after subtracting what we have
-
-							if i.Quantity > 0 { //  If the recipe still needs more of the ingredient we have
-								i.Unit = tempOriginalUnit
-								i.Quantity *= unitPerSpoon     //total units for spoons
-								i = CalcRemaining(i, j, false) //calculate nutrition with new quantity
-								i.Unit = tempUnit
-								i.Quantity /= unitPerSpoon //calculates back to spoon quantity
-								recipeTemp.Ingredients.Missing = append(recipeTemp.Ingredients.Missing, i)
-							}
-						} else {
-							recipeTemp.Ingredients.Have = append(recipeTemp.Ingredients.Have, i)
-							j = CalcRemaining(j, i, true)
-							recipeTemp.Ingredients.Remaining[n] = j
-						}
-					} else {
-						ConvertUnit(&j, tempUnit) //sets both ingredients to the recipes unit
-
-						if j.Quantity <= i.Quantity { //If recipe needs more than what was sent
-							//adds the ingredients sent to 'have'
-							recipeTemp.Ingredients.Have = append(recipeTemp.Ingredients.Have, j)
-							//deletes the ingredient from remaining:
-							recipeTemp.Ingredients.Remaining =
-								append(recipeTemp.Ingredients.Remaining[:n], recipeTemp.Ingredients.Remaining[n+1:]...)
-
-							i.Quantity -= j.Quantity //calculates the 'missing' quantities
-
-							if i.Quantity > 0 {
-								i = CalcRemaining(i, j, false) //calculate nutrition with new quantity
-								ConvertUnit(&i, tempUnit)      //set unit back to recipes unit
-								recipeTemp.Ingredients.Missing = append(recipeTemp.Ingredients.Missing, i)
-							}
-						} else {
-							recipeTemp.Ingredients.Have = append(recipeTemp.Ingredients.Have, i)
-							j = CalcRemaining(j, i, true) //removes i's quantity from j and calculates the new nutrition value
-							recipeTemp.Ingredients.Remaining[n] = j
-						}
-						break //break out after finding matching name
-					}
-				}
-			}
+	//  Allow missing determines if we want to see the recipes we can make even though we're missing some ingredients
+	allowMissing, err := strconv.ParseBool(r.URL.Query().Get("allowMissing")) //reads the allowMissing bool from query
 
-			if !found { //adds the ingredient to 'missing' if not found
-				recipeTemp.Ingredients.Missing = append(recipeTemp.Ingredients.Missing, i)
-			}
-		} //  Allow missing determines if we want to see the recipes we can make even though we're missing some ingredients
-
-		allowMissing, err := strconv.ParseBool(r.URL.Query().Get("allowMissing")) //reads the allowMissing bool from query
-
-		if err != nil {
-			allowMissing = true //sets to true if not set or set to non-boolean
-		}
+	if err != nil {
+		allowMissing = true //sets to true if not set or set to non-boolean
+	}
 
-		if allowMissing || len(recipeTemp.Ingredients.Missing) == 0 {
-			//appends the recipe if it is allowed to be missing, or there are no ingredients missing
-			recipeCount = append(recipeCount, recipeTemp) //adds recipeTemp in the recipeCount
-		}
+	// The recipes are ranked by how many of their ingredients are in the meal, which is counted in the ingredient to
+	// recipe index, so only the recipes that can be inside limit are matched against the meal
+	matches, err := recipeIndex.Matches(ingredientsList, w)
+	if err != nil {
+		http.Error(w, "Failed to retrieve recipes "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	sortBy := strings.ToLower(QueryGet("sortBy", "missing", r))
-
-	switch sortBy {
-	case "have": //  Sorts the recipes in an descending order of the most ingredients in "have" to least in the recipes
-		sort.Slice(recipeCount, func(i, j int) bool {
-			return len(recipeCount[i].Ingredients.Have) > len(recipeCount[j].Ingredients.Have)
-		})
-	case "remaining":
-		//  Sorts the recipes in an ascending order of the least ingredients in "remaining" to most in the recipes
-		sort.Slice(recipeCount, func(i, j int) bool {
-			return len(recipeCount[i].Ingredients.Remaining) < len(recipeCount[j].Ingredients.Remaining)
-		})
-	default: //sorts by missing if not defined
-		//  Sorts the recipes in an ascending order of the least ingredients in "missing" to most in the recipes
-		sort.Slice(recipeCount, func(i, j int) bool {
-			return len(recipeCount[i].Ingredients.Missing) < len(recipeCount[j].Ingredients.Missing)
-		})
+	order, ok := mealOrders[strings.ToLower(QueryGet("sortBy", "missing", r))]
+	if !ok {
+		order = mealOrders["missing"] //sorts by missing if not defined
 	}
 
 	limit, err := strconv.Atoi(QueryGet("limit", "5", r)) //reads limit if sendt, else set it to 5
 
-	if err != nil {
+	if err != nil || limit < 0 {
 		limit = 5
 	}
 
-	if limit < len(recipeCount) { //if there are more than limit
-		recipeCount = recipeCount[:limit] //sets recipecount to cut off all recipes after the value of limit
+	//  Contains the recipes the user can make with ingredients at hand, including the ones which the user
+	//  potentially could make
+	recipeCount := matchMeal(ingredientsList, matches, order, allowMissing, limit)
+
+	for i := range recipeCount {
+		DisplayIngredients(recipeCount[i].Ingredients.Have, units)
+		DisplayIngredients(recipeCount[i].Ingredients.Missing, units)
+		DisplayIngredients(recipeCount[i].Ingredients.Remaining, units)
 	}
 
-	err = json.NewEncoder(w).Encode(recipeCount)
+	if autocorrect {
+		err = json.NewEncoder(w).Encode(MealPrint{Recipes: recipeCount, Corrections: corrections})
+	} else {
+		err = json.NewEncoder(w).Encode(recipeCount)
+	}
 
 	if err != nil {
 		http.Error(w, "Couldn't encode response: "+err.Error(), http.StatusBadRequest)
 	}
 }
+
+// mealOrder is an order of the recipes of a meal, the recipe with the smallest key first. bound is the smallest key
+// a recipe can get, from how many of its ingredients are in the meal
+type mealOrder struct {
+	key   func(rec *RecipePrint) int
+	bound func(m RecipeMatch, meal []Ingredient) int
+}
+
+// mealOrders are the orders of sortBy. Every ingredient of a recipe that is in the meal is either in have, or in
+// missing if there is too little of it, and uses up at most one of the remaining ingredients
+var mealOrders = map[string]mealOrder{
+	"have": { //  Descending order of the most ingredients in "have" to least in the recipes
+		key:   func(rec *RecipePrint) int { return -len(rec.Ingredients.Have) },
+		bound: func(m RecipeMatch, meal []Ingredient) int { return -m.Matches },
+	},
+	"missing": { //  Ascending order of the least ingredients in "missing" to most in the recipes
+		key:   func(rec *RecipePrint) int { return len(rec.Ingredients.Missing) },
+		bound: func(m RecipeMatch, meal []Ingredient) int { return len(m.Recipe.Ingredients) - m.Matches },
+	},
+	"remaining": { //  Ascending order of the least ingredients in "remaining" to most in the recipes
+		key:   func(rec *RecipePrint) int { return len(rec.Ingredients.Remaining) },
+		bound: func(m RecipeMatch, meal []Ingredient) int { return len(meal) - m.Matches },
+	},
+}
+
+// matchMeal returns at most limit recipes in the order, with what the meal has, is missing and has left of their
+// ingredients. The recipes are matched in the order of their bounds, until no recipe left can get inside limit.
+// A recipe without any of the ingredients is missing all of its ingredients, and is not matched
+func matchMeal(meal []Ingredient, matches []RecipeMatch, order mealOrder, allowMissing bool, limit int) []RecipePrint {
+	sort.Slice(matches, func(i, j int) bool {
+		bi, bj := order.bound(matches[i], meal), order.bound(matches[j], meal)
+		if bi != bj {
+			return bi < bj
+		}
+
+		if matches[i].Recipe.RecipeName != matches[j].Recipe.RecipeName {
+			return matches[i].Recipe.RecipeName < matches[j].Recipe.RecipeName
+		}
+
+		return matches[i].Recipe.ID < matches[j].Recipe.ID
+	})
+
+	recipes := []RecipePrint{}
+	keys := []int{} // keys of the matched recipes, sorted
+
+	for _, m := range matches {
+		if limit == 0 || len(keys) >= limit && order.bound(m, meal) > keys[limit-1] {
+			break // every recipe left is after the ones matched
+		}
+
+		if !allowMissing && m.Matches < len(m.Recipe.Ingredients) {
+			continue // some ingredient is not in the meal at all
+		}
+
+		recipeTemp := RecipePrint{RecipeName: m.Recipe.RecipeName}
+
+		if m.Matches == 0 {
+			recipeTemp.Ingredients.Missing = append(recipeTemp.Ingredients.Missing, m.Recipe.Ingredients...)
+			recipeTemp.Ingredients.Remaining = append(recipeTemp.Ingredients.Remaining, meal...)
+		} else {
+			recipeTemp = matchRecipe(m.Recipe, meal)
+		}
+
+		if !allowMissing && len(recipeTemp.Ingredients.Missing) > 0 {
+			continue // there is too little of some ingredient
+		}
+
+		key := order.key(&recipeTemp)
+		n := sort.SearchInts(keys, key+1) // after the recipes with the same key
+
+		keys = append(keys, 0)
+		copy(keys[n+1:], keys[n:])
+		keys[n] = key
+
+		recipes = append(recipes, recipeTemp)
+	}
+
+	sort.SliceStable(recipes, func(i, j int) bool {
+		return order.key(&recipes[i]) < order.key(&recipes[j])
+	})
+
+	if limit < len(recipes) { //if there are more than limit
+		recipes = recipes[:limit] //cuts off all recipes after the value of limit
+	}
+
+	return recipes
+}
+
+// matchRecipe matches the ingredients of a recipe against the meal, and returns what the meal has, is missing and
+// has left of them
+func matchRecipe(list Recipe, meal []Ingredient) RecipePrint {
+	var err error
+
+	recipeTemp := RecipePrint{}
+	recipeTemp.RecipeName = list.RecipeName //  Appends the remaining ingredients to a list
+	recipeTemp.Ingredients.Remaining = append(recipeTemp.Ingredients.Remaining, meal...)
+
+	for _, i := range list.Ingredients { //i is the ingredient needed for the recipe
+		found := false //sets found to true if ingredient is in recipe
+
+		for n, j := range recipeTemp.Ingredients.Remaining { //Name|quantity of ingredients from query
+			if NormalizeName(j.Name) == NormalizeName(i.Name) { //if it matches ingredient from recipe
+				tempUnit := i.Unit //saves the unit the recipe is based on
+
+				j, err = CalcRemaining(j, i, false) //calculates nutritional value for j
+				if err != nil {                     //units can't be compared, i.e. recipe in g and query in l
+					continue
+				}
+
+				found = true //found ingredient
+
+				_ = ConvertUnit(&j, tempUnit) //sets both ingredients to the recipes unit
+
+				if j.Quantity <= i.Quantity { //If recipe needs more than what was sent
+					//adds the ingredients sent to 'have'
+					recipeTemp.Ingredients.Have = append(recipeTemp.Ingredients.Have, j)
+					//deletes the ingredient from remaining:
+					recipeTemp.Ingredients.Remaining =
+						append(recipeTemp.Ingredients.Remaining[:n], recipeTemp.Ingredients.Remaining[n+1:]...)
+
+					needed := i              //what the recipe needs in total
+					i.Quantity -= j.Quantity //calculates the 'missing' quantities
+
+					if i.Quantity > 0 {
+						i, _ = CalcRemaining(i, needed, false) //calculate nutrition with new quantity, units already match
+						recipeTemp.Ingredients.Missing = append(recipeTemp.Ingredients.Missing, i)
+					}
+				} else {
+					recipeTemp.Ingredients.Have = append(recipeTemp.Ingredients.Have, i)
+					j, _ = CalcRemaining(j, i, true) //removes i's quantity from j and calculates the new nutrition value
+					recipeTemp.Ingredients.Remaining[n] = j
+				}
+				break //break out after finding matching name
+			}
+		}
+
+		if !found { //adds the ingredient to 'missing' if not found
+			recipeTemp.Ingredients.Missing = append(recipeTemp.Ingredients.Missing, i)
+		}
+	}
+
+	return recipeTemp
+}
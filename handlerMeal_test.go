@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -76,3 +77,119 @@ func ALLMethodMeal(m string, url string, s []Ingredient, t *testing.T) *http.Res
 
 	return resp
 }
+
+func TestHandlerMealAutocorrect(t *testing.T) {
+	ing := Ingredient{Name: "testcinnamon", Unit: "g", Quantity: 1}
+
+	err := DBSaveIngredient(&ing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBDelete(ing.ID, IngredientCollection, nil)
+
+	// without autocorrect the typo is not read as testcinnamon, and the response is the list of recipes
+	w := httptest.NewRecorder()
+	HandlerMeal(w, httptest.NewRequest(http.MethodGet, "/cravings/meal/?ingredient=10+g+testcinamon", nil))
+
+	recipes := []RecipePrint{}
+	if err := json.NewDecoder(w.Body).Decode(&recipes); err != nil {
+		t.Error("expected a list of recipes without autocorrect", err)
+	}
+
+	w = httptest.NewRecorder()
+	HandlerMeal(w, httptest.NewRequest(http.MethodGet, "/cravings/meal/?ingredient=10+g+testcinamon&autocorrect=true", nil))
+
+	meal := MealPrint{}
+	if err := json.NewDecoder(w.Body).Decode(&meal); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ItemCorrection{Item: 1, Input: "testcinamon", ReadAs: "testcinnamon"}
+	if len(meal.Corrections) != 1 || meal.Corrections[0] != expected {
+		t.Error("expected testcinamon to be read as testcinnamon, got", meal.Corrections)
+	}
+
+	fmt.Println("testing handlerMeal autocorrect")
+}
+
+func TestHandlerMealAllowMissing(t *testing.T) {
+	rec := Recipe{RecipeName: "testzorp cake", Ingredients: []Ingredient{{Name: "testzorp", Quantity: 1, Unit: "pc"}}}
+
+	err := DBSaveRecipe(&rec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBDelete(rec.ID, RecipeCollection, nil)
+
+	// has returns true if the recipe is in the response to the query
+	has := func(query string) bool {
+		w := httptest.NewRecorder()
+		HandlerMeal(w, httptest.NewRequest(http.MethodGet, "/cravings/meal/?ingredient=2+l+milk&limit=1000"+query, nil))
+
+		recipes := []RecipePrint{}
+		if err := json.NewDecoder(w.Body).Decode(&recipes); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, r := range recipes {
+			if r.RecipeName == rec.RecipeName {
+				return len(r.Ingredients.Missing) == 1 && len(r.Ingredients.Have) == 0
+			}
+		}
+
+		return false
+	}
+
+	// a recipe without any of the ingredients is missing all of its ingredients
+	if !has("") || !has("&allowMissing=true") {
+		t.Error("expected recipe without any of the ingredients when ingredients can be missing")
+	}
+
+	if has("&allowMissing=false") {
+		t.Error("recipe without any of the ingredients should not be in the response when none can be missing")
+	}
+
+	fmt.Println("testing handlerMeal allowMissing")
+}
+
+func TestMatchMeal(t *testing.T) {
+	meal := []Ingredient{{Name: "testmilk", Quantity: 1, Unit: "l"}, {Name: "testegg", Quantity: 2, Unit: "pc"}}
+	matches := []RecipeMatch{
+		{Recipe: Recipe{RecipeName: "testbread", Ingredients: []Ingredient{{Name: "testflour", Quantity: 1, Unit: "g"},
+			{Name: "testyeast", Quantity: 1, Unit: "g"}}}, Matches: 0},
+		{Recipe: Recipe{RecipeName: "testpancakes", Ingredients: []Ingredient{{Name: "testmilk", Quantity: 0.5, Unit: "l"},
+			{Name: "testegg", Quantity: 3, Unit: "pc"}, {Name: "testflour", Quantity: 1, Unit: "g"}}}, Matches: 2},
+		{Recipe: Recipe{RecipeName: "testomelette", Ingredients: []Ingredient{{Name: "testegg", Quantity: 2, Unit: "pc"}}},
+			Matches: 1},
+	}
+
+	names := func(recipes []RecipePrint) string {
+		var n []string
+
+		for _, r := range recipes {
+			n = append(n, r.RecipeName)
+		}
+
+		return strings.Join(n, " ")
+	}
+
+	// omelette is missing nothing, pancakes are missing flour and one egg, bread is missing both its ingredients
+	recipes := matchMeal(meal, matches, mealOrders["missing"], true, 5)
+	if names(recipes) != "testomelette testpancakes testbread" {
+		t.Error("expected recipes by missing, got", names(recipes))
+	}
+
+	if len(recipes[2].Ingredients.Missing) != 2 || len(recipes[2].Ingredients.Remaining) != 2 {
+		t.Error("recipe without any of the ingredients should be missing all of them", recipes[2])
+	}
+
+	if recipes = matchMeal(meal, matches, mealOrders["have"], true, 1); names(recipes) != "testpancakes" {
+		t.Error("expected pancakes with the most ingredients in have, got", names(recipes))
+	}
+
+	if recipes = matchMeal(meal, matches, mealOrders["missing"], false, 5); names(recipes) != "testomelette" {
+		t.Error("expected only the recipe missing nothing, got", names(recipes))
+	}
+
+	fmt.Println("testing matchMeal")
+}
@@ -10,53 +10,48 @@ import (
 func HandlerStatus(w http.ResponseWriter, r *http.Request) {
 	var S Status
 
-	APIURL := "https://api.edamam.com/api/nutrition-data?app_id="
-	APIURL += AppID
-	APIURL += "&app_key="
-	APIURL += AppKey
-	APIURL += "&ingr=1%20large%20apple"
-
 	// Sets status for Edamam ***************************************
-	resp, err := http.Get(APIURL) // gets api
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	if resp != nil {
-		defer resp.Body.Close()
+	S.EdamamQuota = edamamQuota.Remaining() // sets calls left to edamam
+
+	switch { // edamam is not called, so the check uses no quota
+	case S.EdamamQuota.Minute == 0 || S.EdamamQuota.Day == 0:
+		S.Edamam = http.StatusTooManyRequests
+	case BreakerState(OutboundEdamam) == BreakerOpen:
+		S.Edamam = http.StatusServiceUnavailable // the last calls to edamam failed
+	default:
+		S.Edamam = http.StatusOK
 	}
 
-	S.Edamam = resp.StatusCode // sets status code for api
-
 	// Sets staus for database ***************************************
-	resp, err = http.Get("https://firebase.google.com") // gets link
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+	S.Database = http.StatusOK // the in-memory and bolt databases are in this process
 
-	if resp != nil {
-		defer resp.Body.Close()
-	}
+	if _, ok := Database.(*FirestoreDatabase); ok {
+		resp, err := OutboundClient(OutboundFirebase).Get("https://firebase.google.com") // gets link
+		if err != nil {
+			S.Database = http.StatusServiceUnavailable // timed out, unreachable or circuit breaker is open
+		} else {
+			defer resp.Body.Close()
 
-	S.Database = resp.StatusCode // sets status code for link
+			S.Database = resp.StatusCode // sets status code for link
+		}
+	}
 
 	// Sets total of recipes *****************************************
-	statusRecipe, err := DBReadAllRecipes(w) // gets all recipes from database
+	var err error
+
+	S.TotalRecipe, err = DBCountRecipes(w) // counts the recipes in the read cache
 	if err != nil {
 		http.Error(w, "Could not retrieve collection "+RecipeCollection+" "+err.Error(), http.StatusInternalServerError)
 	}
 
-	S.TotalRecipe = len(statusRecipe) // sets totat for recipes
-
 	// Sets status for Ingredients ***********************************
-	statusIngredients, err := DBReadAllIngredients(w) // gets all ingredients from database
+	S.TotalIngredients, err = DBCountIngredients(w) // counts the ingredients in the read cache
 	if err != nil {
 		http.Error(w, "Could not retrieve collection "+IngredientCollection+" "+err.Error(), http.StatusInternalServerError)
 	}
 
-	S.TotalIngredients = len(statusIngredients) // sers total for ingredients
+	// Sets status for outbound calls ********************************
+	S.Outbound = BreakerStatuses() // state of the circuit breaker for each destination
 
 	// Sets status for uptime ****************************************
 	elapse := time.Since(StartTime) //sets run time
@@ -49,16 +49,6 @@ func TestHandlerWebhooks(t *testing.T) {
 		t.Error(err)
 	}
 
-	resp = ALLMethodWebhook(Get, URL+wh[1].ID, s, t)
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK { // check that test went ok
-		t.Error(resp.StatusCode)
-	}
-
-	// Test Delete method for endpoint /cravings/webhooks/
-	fmt.Println("testing webhooks DELETE method")
-
 	var temp string
 
 	fmt.Println("webH: ", webH.Event)
@@ -74,6 +64,20 @@ func TestHandlerWebhooks(t *testing.T) {
 
 	fmt.Println("temp: ", temp)
 
+	if temp == "" {
+		t.Fatal("posted webhook not found")
+	}
+
+	resp = ALLMethodWebhook(Get, URL+temp, s, t)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK { // check that test went ok
+		t.Error(resp.StatusCode)
+	}
+
+	// Test Delete method for endpoint /cravings/webhooks/
+	fmt.Println("testing webhooks DELETE method")
+
 	tempstruct := Webhook{ID: temp} // creates temp struct to send with request
 
 	resp = ALLMethodWebhook(Delete, URL, tempstruct, t)
@@ -0,0 +1,67 @@
+package cravings
+
+import (
+	"net/http"
+)
+
+// IngredientCache finds the ingredients in the read cache by their name and aliases, so looking up an ingredient
+// does not read the whole collection
+type IngredientCache struct {
+	exact   nameIndex // IDs by name
+	names   nameIndex // IDs by normalised name
+	aliases nameIndex // IDs by normalised alias
+}
+
+// ingredientCache is the cache used to look up ingredients by name
+var ingredientCache = &IngredientCache{}
+
+// resetIngredients takes every ingredient out of the cache
+func (c *IngredientCache) resetIngredients() {
+	c.exact = nameIndex{}
+	c.names = nameIndex{}
+	c.aliases = nameIndex{}
+}
+
+// addIngredient adds an ingredient by its name and aliases
+func (c *IngredientCache) addIngredient(ing Ingredient) {
+	c.exact.add(ing.Name, ing.ID)
+	c.names.add(NormalizeName(ing.Name), ing.ID)
+
+	for _, alias := range ing.Aliases {
+		c.aliases.add(NormalizeName(alias), ing.ID)
+	}
+}
+
+// removeIngredient takes the ingredient with the ID out of the cache
+func (c *IngredientCache) removeIngredient(id string) {
+	ing := readCache.ingredients[id]
+
+	c.exact.remove(ing.Name, id)
+	c.names.remove(NormalizeName(ing.Name), id)
+
+	for _, alias := range ing.Aliases {
+		c.aliases.remove(NormalizeName(alias), id)
+	}
+}
+
+// Find returns the ingredient with the given name or alias. An ingredient with exactly the name is found first, then one
+// with the same normalised name and then one with it as an alias. If more than one ingredient has the name the one
+// with the lowest ID is found. If it is not found the error is a *NotFoundError with the closest ingredient names
+func (c *IngredientCache) Find(name string, w http.ResponseWriter) (Ingredient, error) {
+	err := readCache.rlock(false, true)
+	if err != nil {
+		return Ingredient{}, err
+	}
+	defer readCache.mu.RUnlock()
+
+	for _, id := range []string{c.exact.first(name), c.names.first(NormalizeName(name)), c.aliases.first(NormalizeName(name))} {
+		if id != "" {
+			return copyIngredient(readCache.ingredients[id]), nil
+		}
+	}
+
+	ingredients := readCache.ingredientsInOrder(SortName, ingredientOrders[SortName]) // the same names every time
+
+	return Ingredient{}, &NotFoundError{Kind: "ingredient", Name: name,
+		Suggestions: SuggestIngredients(name, ingredients, MaxSuggestions)}
+}
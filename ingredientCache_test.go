@@ -0,0 +1,74 @@
+package cravings
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIngredientCache(t *testing.T) {
+	ing := Ingredient{Name: "testaubergine", Unit: "g", Quantity: 1, Aliases: []string{"testeggplant"}}
+
+	err := DBSaveIngredient(&ing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"testaubergine", "Testaubergines", "testeggplant"} {
+		found, err := ingredientCache.Find(name, nil)
+		if err != nil || found.ID != ing.ID {
+			t.Error("expected testaubergine for "+name+", got", found, err)
+		}
+	}
+
+	err = DBDelete(ing.ID, IngredientCollection, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ingredientCache.Find("testeggplant", nil); err == nil {
+		t.Error("expected the alias of the deleted ingredient to be gone")
+	}
+
+	_, err = ingredientCache.Find("testaubergine", nil)
+
+	e, ok := notFound(err)
+	if !ok {
+		t.Fatal("expected *NotFoundError for deleted ingredient, got", err)
+	}
+
+	for _, suggestion := range e.Suggestions {
+		if suggestion == "testaubergine" {
+			t.Error("deleted ingredient is still suggested", e.Suggestions)
+		}
+	}
+
+	fmt.Println("testing IngredientCache")
+}
+
+func TestIngredientCacheDuplicateNames(t *testing.T) {
+	first := Ingredient{Name: "testtwin", Unit: "g", Quantity: 1, Aliases: []string{"testtwins", "testtwins"}}
+	second := Ingredient{Name: "testtwin", Unit: "g", Quantity: 1}
+
+	_ = DBSaveIngredient(&first, nil)
+	_ = DBSaveIngredient(&second, nil)
+	defer DBDelete(second.ID, IngredientCollection, nil)
+
+	_ = DBDelete(first.ID, IngredientCollection, nil) // the other ingredient with the name is still found
+
+	found, err := ingredientCache.Find("testtwin", nil)
+	if err != nil || found.ID != second.ID {
+		t.Error("expected the remaining ingredient with the name, got", found, err)
+	}
+
+	// both names are normalised to testtwin, but the exact name is found first
+	plural := Ingredient{Name: "testtwins", Unit: "g", Quantity: 1}
+	_ = DBSaveIngredient(&plural, nil)
+	defer DBDelete(plural.ID, IngredientCollection, nil)
+
+	found, err = DBFindIngredient("testtwins", nil)
+	if err != nil || found.ID != plural.ID {
+		t.Error("expected the ingredient with exactly the name, got", found, err)
+	}
+
+	fmt.Println("testing IngredientCache duplicate names")
+}
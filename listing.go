@@ -0,0 +1,285 @@
+package cravings
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultListLimit is how many recipes or ingredients are in a page if limit is not set
+const DefaultListLimit = 20
+
+// MaxListLimit is the most recipes or ingredients in a page
+const MaxListLimit = 100
+
+// Ways a list of recipes or ingredients can be sorted, "-" before one of them sorts in descending order
+const (
+	SortName     = "name"
+	SortCalories = "calories"
+)
+
+// listParameters are the query parameters for a page of recipes or ingredients, the whole list is returned without them
+var listParameters = []string{"limit", "cursor", "sort", "prefix", "maxCalories", "ingredient"}
+
+// ListOptions are how a list of recipes or ingredients is filtered, sorted and split into pages
+type ListOptions struct {
+	Paged          bool    // Any of the list parameters are set, so a page is returned instead of the whole list
+	Limit          int     // Most recipes or ingredients in the page
+	Cursor         string  // Where the page starts, from the previous page
+	Sort           string  // SortName or SortCalories, with "-" before it in descending order. Empty is by ID
+	Prefix         string  // Only names starting with this
+	MaxCalories    float64 // Only recipes or ingredients with at most this many calories, if HasMaxCalories
+	HasMaxCalories bool
+	Ingredient     string // Only recipes using this ingredient
+}
+
+// recipeOrders are the ways recipes can be sorted in memory, by the sort parameter
+var recipeOrders = map[string]func(a *Recipe, b *Recipe) bool{
+	SortName: func(a *Recipe, b *Recipe) bool { return suggestKey(a.RecipeName) < suggestKey(b.RecipeName) },
+	"-" + SortName: func(a *Recipe, b *Recipe) bool {
+		return suggestKey(a.RecipeName) > suggestKey(b.RecipeName)
+	},
+	SortCalories: func(a *Recipe, b *Recipe) bool {
+		return a.AllNutrients.Energy.Quantity < b.AllNutrients.Energy.Quantity
+	},
+	"-" + SortCalories: func(a *Recipe, b *Recipe) bool {
+		return a.AllNutrients.Energy.Quantity > b.AllNutrients.Energy.Quantity
+	},
+}
+
+// ingredientOrders are the ways ingredients can be sorted in memory, by the sort parameter
+var ingredientOrders = map[string]func(a *Ingredient, b *Ingredient) bool{
+	SortName:           func(a *Ingredient, b *Ingredient) bool { return suggestKey(a.Name) < suggestKey(b.Name) },
+	"-" + SortName:     func(a *Ingredient, b *Ingredient) bool { return suggestKey(a.Name) > suggestKey(b.Name) },
+	SortCalories:       func(a *Ingredient, b *Ingredient) bool { return a.Calories < b.Calories },
+	"-" + SortCalories: func(a *Ingredient, b *Ingredient) bool { return a.Calories > b.Calories },
+}
+
+// ReadListOptions reads how to filter, sort and split a list into pages from the query
+func ReadListOptions(r *http.Request) (ListOptions, error) {
+	query := r.URL.Query()
+	o := ListOptions{Limit: DefaultListLimit}
+
+	for _, parameter := range listParameters {
+		if _, ok := query[parameter]; ok {
+			o.Paged = true
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return o, errors.New("limit has to be a number more than 0")
+		}
+
+		if n > MaxListLimit {
+			n = MaxListLimit
+		}
+
+		o.Limit = n
+	}
+
+	if sortBy := query.Get("sort"); sortBy != "" {
+		if _, ok := recipeOrders[sortBy]; !ok {
+			return o, errors.New("sort has to be " + SortName + " or " + SortCalories + ", with - before it in descending order")
+		}
+
+		o.Sort = sortBy
+	}
+
+	if maxCalories := query.Get("maxCalories"); maxCalories != "" {
+		n, err := strconv.ParseFloat(maxCalories, 64)
+		if err != nil || n < 0 {
+			return o, errors.New("maxCalories has to be a number, 0 or more")
+		}
+
+		o.MaxCalories, o.HasMaxCalories = n, true
+	}
+
+	o.Cursor = query.Get("cursor")
+	o.Prefix = suggestKey(query.Get("prefix"))
+	o.Ingredient = strings.TrimSpace(query.Get("ingredient"))
+
+	return o, nil
+}
+
+// filtered returns true if only some of the recipes or ingredients are listed
+func (o ListOptions) filtered() bool {
+	return o.Prefix != "" || o.HasMaxCalories || o.Ingredient != ""
+}
+
+// keep returns true if the name and calories are not filtered away
+func (o ListOptions) keep(name string, calories float64) bool {
+	if !strings.HasPrefix(suggestKey(name), o.Prefix) {
+		return false
+	}
+
+	return !o.HasMaxCalories || calories <= o.MaxCalories
+}
+
+// take returns the positions of at most limit of the n documents from start that keep returns true for, and true
+// if there are more of them after the last one
+func take(n int, keep func(i int) bool, start int, limit int) ([]int, bool) {
+	var positions []int
+
+	for i := start; i < n; i++ {
+		if !keep(i) {
+			continue
+		}
+
+		if len(positions) == limit {
+			return positions, true
+		}
+
+		positions = append(positions, i)
+	}
+
+	return positions, false
+}
+
+// ListRecipes returns a page of the recipes, filtered and sorted by the options, and the cursor of the next page,
+// which is the ID of the last recipe in the page. Without a sort or a filter the page is read from the database, see
+// DBReadRecipesPage. Otherwise the recipes are from the read cache, so a filter does not read every page of the
+// database. Calories of a recipe are the energy of all its ingredients
+func ListRecipes(o ListOptions, w http.ResponseWriter) ([]Recipe, string, error) {
+	if o.Sort == "" && !o.filtered() {
+		return DBReadRecipesPage(o.Cursor, o.Limit, w)
+	}
+
+	ingredient := Ingredient{Name: o.Ingredient}
+
+	if o.Ingredient != "" {
+		found, err := DBFindIngredient(o.Ingredient, w) // so recipes using it by an alias are found too
+		if err == nil {
+			ingredient = found
+		} else if _, ok := notFound(err); !ok {
+			return []Recipe{}, "", err
+		}
+	}
+
+	keep := func(rec *Recipe) bool {
+		return o.keep(rec.RecipeName, rec.AllNutrients.Energy.Quantity) &&
+			(o.Ingredient == "" || usesIngredient(*rec, ingredient))
+	}
+
+	err := readCache.rlock(true, false)
+	if err != nil {
+		return []Recipe{}, "", err
+	}
+	defer readCache.mu.RUnlock()
+
+	recipes := readCache.recipesInOrder(o.Sort, recipeOrders[o.Sort])
+
+	start, err := cursorPosition(o.Cursor, o.Sort, len(recipes), func(i int) string { return recipes[i].ID })
+	if err != nil {
+		return []Recipe{}, "", err
+	}
+
+	page := []Recipe{}
+
+	positions, more := take(len(recipes), func(i int) bool { return keep(&recipes[i]) }, start, o.Limit)
+	for _, i := range positions {
+		page = append(page, copyRecipe(recipes[i]))
+	}
+
+	if more {
+		return page, page[len(page)-1].ID, nil
+	}
+
+	return page, "", nil
+}
+
+// cursorPosition returns where a page of documents in the order starts, after the document with the cursor as ID.
+// In ID order the page starts after the cursor even if its document has been deleted, like in the database
+func cursorPosition(cursor string, order string, n int, id func(i int) string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	if order == "" {
+		return sort.Search(n, func(i int) bool { return id(i) > cursor }), nil
+	}
+
+	for i := 0; i < n; i++ {
+		if id(i) == cursor {
+			return i + 1, nil
+		}
+	}
+
+	return 0, errors.New("cursor is not in the list anymore, start again from the first page")
+}
+
+// usesIngredient returns true if the recipe has the ingredient by its name or one of its aliases
+func usesIngredient(rec Recipe, ing Ingredient) bool {
+	for _, i := range rec.Ingredients {
+		if ing.HasName(i.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListIngredients returns a page of the ingredients, filtered and sorted by the options, and the cursor of the next
+// page, see ListRecipes
+func ListIngredients(o ListOptions, w http.ResponseWriter) ([]Ingredient, string, error) {
+	if o.Ingredient != "" {
+		return []Ingredient{}, "", errors.New("ingredient can only be used to filter recipes")
+	}
+
+	if o.Sort == "" && !o.filtered() {
+		return DBReadIngredientsPage(o.Cursor, o.Limit, w)
+	}
+
+	keep := func(ing *Ingredient) bool {
+		return o.keep(ing.Name, ing.Calories)
+	}
+
+	err := readCache.rlock(false, true)
+	if err != nil {
+		return []Ingredient{}, "", err
+	}
+	defer readCache.mu.RUnlock()
+
+	ingredients := readCache.ingredientsInOrder(o.Sort, ingredientOrders[o.Sort])
+
+	start, err := cursorPosition(o.Cursor, o.Sort, len(ingredients), func(i int) string { return ingredients[i].ID })
+	if err != nil {
+		return []Ingredient{}, "", err
+	}
+
+	page := []Ingredient{}
+
+	positions, more := take(len(ingredients), func(i int) bool { return keep(&ingredients[i]) }, start, o.Limit)
+	for _, i := range positions {
+		page = append(page, copyIngredient(ingredients[i]))
+	}
+
+	if more {
+		return page, page[len(page)-1].ID, nil
+	}
+
+	return page, "", nil
+}
+
+// writeListPage writes the page in a ListPage, with a Link header to the next page if there is one
+func writeListPage(w http.ResponseWriter, r *http.Request, items interface{}, next string) error {
+	page := ListPage{Items: items, NextCursor: next}
+
+	if next != "" {
+		query := r.URL.Query()
+		query.Set("cursor", next)
+
+		link := *r.URL
+		link.RawQuery = query.Encode()
+		page.Next = link.RequestURI()
+
+		w.Header().Set("Link", "<"+page.Next+">; rel=\"next\"")
+	}
+
+	return json.NewEncoder(w).Encode(&page)
+}
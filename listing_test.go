@@ -0,0 +1,181 @@
+package cravings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// listRequest returns the list options read from the query
+func listRequest(t *testing.T, query string) ListOptions {
+	o, err := ReadListOptions(httptest.NewRequest(http.MethodGet, "/cravings/food/recipe?"+query, nil))
+	if err != nil {
+		t.Fatal(query, err)
+	}
+
+	return o
+}
+
+func TestReadListOptions(t *testing.T) {
+	if o := listRequest(t, "units=metric"); o.Paged {
+		t.Error("expected the whole list without list parameters")
+	}
+
+	o := listRequest(t, "limit=1000&sort=-calories&maxCalories=0&prefix=Pan")
+	if !o.Paged || o.Limit != MaxListLimit || o.Sort != "-calories" || !o.HasMaxCalories || o.Prefix != "pan" {
+		t.Error("unexpected options", o)
+	}
+
+	for _, query := range []string{"limit=0", "limit=a", "sort=weight", "maxCalories=-1"} {
+		_, err := ReadListOptions(httptest.NewRequest(http.MethodGet, "/cravings/food/recipe?"+query, nil))
+		if err == nil {
+			t.Error("expected error for", query)
+		}
+	}
+
+	fmt.Println("testing ReadListOptions")
+}
+
+func TestListRecipes(t *testing.T) {
+	original := Database
+	defer UseDatabase(original) // restore database used by the other tests
+
+	db := &countingDatabase{MemoryDatabase: NewMemoryDatabase()}
+	UseDatabase(db)
+
+	recipes := []Recipe{
+		{RecipeName: "Pancakes", Ingredients: []Ingredient{{Name: "testlistmilk"}}},
+		{RecipeName: "pasta", Ingredients: []Ingredient{{Name: "testlistflour"}}},
+		{RecipeName: "waffles", Ingredients: []Ingredient{{Name: "testlistmilk"}}},
+		{RecipeName: "pizza", Ingredients: []Ingredient{{Name: "testlistflour"}}},
+	}
+
+	for i, calories := range []float64{800, 600, 900, 1200} {
+		recipes[i].AllNutrients.Energy.Quantity = calories
+		_ = DBSaveRecipe(&recipes[i], nil)
+	}
+
+	names := func(page []Recipe) string {
+		var n []string
+		for _, rec := range page {
+			n = append(n, rec.RecipeName)
+		}
+
+		return strings.Join(n, ",")
+	}
+
+	// all reads a query a page at a time, by ID, and returns the names in every page
+	all := func(query string) []string {
+		var pages []string
+
+		for cursor, first := "", true; first || cursor != ""; first = false {
+			page, next, err := ListRecipes(listRequest(t, query+"&cursor="+cursor), nil)
+			if err != nil {
+				t.Fatal(query, err)
+			}
+
+			pages, cursor = append(pages, names(page)), next
+		}
+
+		return pages
+	}
+
+	if pages := all("limit=3"); len(pages) != 2 || len(strings.Split(pages[0]+","+pages[1], ",")) != 4 {
+		t.Error("expected 4 recipes in pages of 3 and 1, got", pages)
+	}
+
+	if db.recipePageReads != 2 {
+		t.Error("expected a page read from the database for each page, got", db.recipePageReads)
+	}
+
+	// filtered pages are from the read cache, so the pages in the database are not all read
+	if pages := all("limit=2&prefix=p"); len(pages) != 2 || strings.Contains(strings.Join(pages, ","), "waffles") {
+		t.Error("expected 3 recipes starting with p in 2 pages, got", pages)
+	}
+
+	if db.recipePageReads != 2 {
+		t.Error("expected no pages read from the database for a filter, got", db.recipePageReads-2)
+	}
+
+	page, next, err := ListRecipes(listRequest(t, "limit=3&sort=name"), nil)
+	if err != nil || names(page) != "Pancakes,pasta,pizza" || next == "" {
+		t.Error("expected first page by name, got", names(page), next, err)
+	}
+
+	page, next, _ = ListRecipes(listRequest(t, "limit=3&sort=name&cursor="+next), nil)
+	if names(page) != "waffles" || next != "" {
+		t.Error("expected last page with waffles, got", names(page), next)
+	}
+
+	page, _, _ = ListRecipes(listRequest(t, "sort=-calories&maxCalories=900"), nil)
+	if names(page) != "waffles,Pancakes,pasta" {
+		t.Error("expected recipes with at most 900 calories, most first, got", names(page))
+	}
+
+	page, _, _ = ListRecipes(listRequest(t, "sort=name&prefix=p&ingredient=testlistflour"), nil)
+	if names(page) != "pasta,pizza" {
+		t.Error("expected recipes starting with p using flour, got", names(page))
+	}
+
+	_ = DBDelete(recipes[0].ID, RecipeCollection, nil)
+
+	_, _, err = ListRecipes(listRequest(t, "sort=calories&cursor="+recipes[0].ID), nil)
+	if err == nil {
+		t.Error("expected error for cursor of a deleted recipe")
+	}
+
+	// by ID the page starts after the deleted recipe, like in the database
+	page, _, err = ListRecipes(listRequest(t, "prefix=p&cursor="+recipes[0].ID), nil)
+	if err != nil || strings.Contains(names(page), "Pancakes") {
+		t.Error("expected recipes after the deleted recipe, got", names(page), err)
+	}
+
+	// the sorted recipes are only sorted again after a change
+	readCache.mu.RLock()
+	first := readCache.recipesInOrder(SortName, recipeOrders[SortName])
+	second := readCache.recipesInOrder(SortName, recipeOrders[SortName])
+	readCache.mu.RUnlock()
+
+	if len(first) != 3 || &first[0] != &second[0] {
+		t.Error("expected the same sorted recipes from the cache")
+	}
+
+	fmt.Println("testing ListRecipes")
+}
+
+func TestHandlerFoodList(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	HandlerFood(w, httptest.NewRequest(http.MethodGet, "/cravings/food/ingredient?limit=1", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatal(w.Code, w.Body.String())
+	}
+
+	page := struct {
+		Items      []Ingredient `json:"items"`
+		NextCursor string       `json:"nextCursor"`
+		Next       string       `json:"next"`
+	}{}
+
+	err := json.NewDecoder(w.Body).Decode(&page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the test database has more than one ingredient
+	if len(page.Items) != 1 || page.NextCursor == "" || !strings.Contains(w.Header().Get("Link"), page.Next) {
+		t.Error("expected one ingredient and a link to the next page, got", page, w.Header().Get("Link"))
+	}
+
+	w = httptest.NewRecorder()
+
+	HandlerFood(w, httptest.NewRequest(http.MethodGet, "/cravings/food/ingredient?ingredient=milk", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Error("expected bad request for ingredient filter on ingredients, got", w.Code)
+	}
+}
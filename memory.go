@@ -0,0 +1,294 @@
+package cravings
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// NewMemoryDatabase returns an empty in-memory database which has the given tokens as approved tokens
+func NewMemoryDatabase(tokens ...string) *MemoryDatabase {
+	db := &MemoryDatabase{
+		recipeNames:     nameIndex{},
+		ingredientNames: nameIndex{},
+		positions:       map[string]int{},
+	}
+
+	for _, t := range tokens {
+		db.tokens = append(db.tokens, Token{ID: newID(), AuthToken: t})
+	}
+
+	return db
+}
+
+// newID returns a random document id, like the ones firestore creates
+func newID() string {
+	b := make([]byte, 10)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err) // crypto/rand only fails if the system has no source of randomness
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// copyRecipe returns a copy of the recipe which does not share slices with the original
+func copyRecipe(r Recipe) Recipe {
+	r.Ingredients = append([]Ingredient(nil), r.Ingredients...)
+	r.Description = append([]string(nil), r.Description...)
+
+	return r
+}
+
+// copyIngredient returns a copy of the ingredient which does not share its aliases with the original
+func copyIngredient(i Ingredient) Ingredient {
+	i.Aliases = append([]string(nil), i.Aliases...)
+
+	return i
+}
+
+// Init does nothing, the in-memory database is ready when created
+func (db *MemoryDatabase) Init() error {
+	return nil
+}
+
+// Close does nothing, the in-memory database has no connection to close
+func (db *MemoryDatabase) Close() error {
+	return nil
+}
+
+// SaveRecipe saves recipe in memory
+func (db *MemoryDatabase) SaveRecipe(r *Recipe) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	r.ID = newID()
+	db.recipes = append(db.recipes, copyRecipe(*r))
+	db.positions[r.ID] = len(db.recipes) - 1
+	db.recipeNames.add(r.RecipeName, r.ID)
+
+	return nil
+}
+
+// SaveIngredient saves ingredient in memory
+func (db *MemoryDatabase) SaveIngredient(i *Ingredient) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	i.ID = newID()
+	db.ingredients = append(db.ingredients, copyIngredient(*i))
+	db.positions[i.ID] = len(db.ingredients) - 1
+	db.ingredientNames.add(i.Name, i.ID)
+
+	return nil
+}
+
+// SaveWebhook saves webhook in memory
+func (db *MemoryDatabase) SaveWebhook(wh *Webhook) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	wh.ID = newID()
+	db.webhooks = append(db.webhooks, *wh)
+
+	return nil
+}
+
+// SaveToken adds an approved token. Only the in-memory and on-disk databases can have tokens added by the program
+func (db *MemoryDatabase) SaveToken(t *Token) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t.ID = newID()
+	db.tokens = append(db.tokens, *t)
+
+	return nil
+}
+
+// Delete deletes an entry from given collection by its id, either ingredient, recipe or webhook
+func (db *MemoryDatabase) Delete(id string, collection string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch collection {
+	case RecipeCollection:
+		if i, ok := db.position(id, len(db.recipes)); ok && db.recipes[i].ID == id {
+			db.recipeNames.remove(db.recipes[i].RecipeName, id)
+			db.recipes = append(db.recipes[:i], db.recipes[i+1:]...)
+			db.reindex()
+
+			return nil
+		}
+	case IngredientCollection:
+		if i, ok := db.position(id, len(db.ingredients)); ok && db.ingredients[i].ID == id {
+			db.ingredientNames.remove(db.ingredients[i].Name, id)
+			db.ingredients = append(db.ingredients[:i], db.ingredients[i+1:]...)
+			db.reindex()
+
+			return nil
+		}
+	case WebhooksCollection:
+		for i := range db.webhooks {
+			if db.webhooks[i].ID == id {
+				db.webhooks = append(db.webhooks[:i], db.webhooks[i+1:]...)
+				return nil
+			}
+		}
+	default:
+		return errors.New("No collection named " + collection)
+	}
+
+	return errors.New("No document with id \"" + id + "\" in " + collection)
+}
+
+// position returns the position of the recipe or ingredient with the id, if it is in a slice of length n
+func (db *MemoryDatabase) position(id string, n int) (int, bool) {
+	i, ok := db.positions[id]
+	return i, ok && i < n
+}
+
+// reindex sets the positions of every recipe and ingredient again, after one has been removed from its slice
+func (db *MemoryDatabase) reindex() {
+	db.positions = map[string]int{}
+
+	for i, r := range db.recipes {
+		db.positions[r.ID] = i
+	}
+
+	for i, ing := range db.ingredients {
+		db.positions[ing.ID] = i
+	}
+}
+
+// ReadRecipeByName reads a single recipe by name through the recipe name index
+func (db *MemoryDatabase) ReadRecipeByName(name string) (Recipe, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if i, ok := db.position(db.recipeNames.first(name), len(db.recipes)); ok && db.recipes[i].RecipeName == name {
+		return copyRecipe(db.recipes[i]), nil
+	}
+
+	return Recipe{}, &NotFoundError{Kind: "recipe", Name: name}
+}
+
+// ReadIngredientByName reads a single ingredient by name through the ingredient name index
+func (db *MemoryDatabase) ReadIngredientByName(name string) (Ingredient, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if i, ok := db.position(db.ingredientNames.first(name), len(db.ingredients)); ok && db.ingredients[i].Name == name {
+		return copyIngredient(db.ingredients[i]), nil
+	}
+
+	return Ingredient{}, &NotFoundError{Kind: "ingredient", Name: name}
+}
+
+// pageIDs returns at most limit of the ids after the cursor in sorted order, and the cursor of the next page
+func pageIDs(ids []string, cursor string, limit int) ([]string, string) {
+	sort.Strings(ids)
+
+	start := sort.SearchStrings(ids, cursor)
+	if start < len(ids) && ids[start] == cursor {
+		start++
+	}
+
+	if start+limit >= len(ids) {
+		return ids[start:], ""
+	}
+
+	return ids[start : start+limit], ids[start+limit-1]
+}
+
+// ReadRecipesPage reads a page of recipes ordered by ID, see Store
+func (db *MemoryDatabase) ReadRecipesPage(cursor string, limit int) ([]Recipe, string, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var ids []string
+	for _, r := range db.recipes {
+		ids = append(ids, r.ID)
+	}
+
+	page, next := pageIDs(ids, cursor, limit)
+	temprecipes := []Recipe{}
+
+	for _, id := range page {
+		temprecipes = append(temprecipes, copyRecipe(db.recipes[db.positions[id]]))
+	}
+
+	return temprecipes, next, nil
+}
+
+// ReadIngredientsPage reads a page of ingredients ordered by ID, see Store
+func (db *MemoryDatabase) ReadIngredientsPage(cursor string, limit int) ([]Ingredient, string, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var ids []string
+	for _, i := range db.ingredients {
+		ids = append(ids, i.ID)
+	}
+
+	page, next := pageIDs(ids, cursor, limit)
+	tempingredients := []Ingredient{}
+
+	for _, id := range page {
+		tempingredients = append(tempingredients, copyIngredient(db.ingredients[db.positions[id]]))
+	}
+
+	return tempingredients, next, nil
+}
+
+// ReadAllRecipes reads all recipes in memory
+func (db *MemoryDatabase) ReadAllRecipes() ([]Recipe, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var temprecipes []Recipe
+
+	for _, r := range db.recipes {
+		temprecipes = append(temprecipes, copyRecipe(r))
+	}
+
+	return temprecipes, nil
+}
+
+// ReadAllIngredients reads all ingredients in memory
+func (db *MemoryDatabase) ReadAllIngredients() ([]Ingredient, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var tempingredients []Ingredient
+
+	for _, i := range db.ingredients {
+		tempingredients = append(tempingredients, copyIngredient(i))
+	}
+
+	return tempingredients, nil
+}
+
+// ReadAllWebhooks reads all webhooks in memory
+func (db *MemoryDatabase) ReadAllWebhooks() ([]Webhook, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return append([]Webhook(nil), db.webhooks...), nil
+}
+
+// CheckToken returns true if token is one of the approved tokens
+func (db *MemoryDatabase) CheckToken(token string) (bool, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, t := range db.tokens {
+		if t.AuthToken == token {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,114 @@
+package cravings
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMemoryDatabase(t *testing.T) {
+	db := NewMemoryDatabase("testtoken")
+
+	ing := Ingredient{Name: "milk", Unit: "l", Quantity: 1}
+
+	err := db.SaveIngredient(&ing) // test saving ingredient
+	if err != nil {
+		t.Error(err)
+	}
+
+	if ing.ID == "" {
+		t.Error("ingredient did not get an id")
+	}
+
+	ing2, err := db.ReadIngredientByName("milk") // test reading ingredient by name
+	if err != nil {
+		t.Error(err)
+	}
+
+	if ing2.ID != ing.ID {
+		t.Error("read wrong ingredient", ing2)
+	}
+
+	alias := Ingredient{Name: "zucchini", Unit: "g", Quantity: 1, Aliases: []string{"courgette"}}
+	_ = db.SaveIngredient(&alias)
+	alias.Aliases[0] = "marrow" // changing the saved ingredient or a read copy should not change the database
+
+	ing3, _ := db.ReadIngredientByName("zucchini")
+	ing3.Aliases[0] = "marrow"
+
+	ingredients, _ := db.ReadAllIngredients()
+	if ingredients[1].Aliases[0] != "courgette" {
+		t.Error("ingredient in database was changed through a copy", ingredients[1])
+	}
+
+	rec := Recipe{RecipeName: "TestRecipe", Ingredients: []Ingredient{ing}}
+
+	err = db.SaveRecipe(&rec) // test saving recipe
+	if err != nil {
+		t.Error(err)
+	}
+
+	rec2, err := db.ReadRecipeByName("TestRecipe") // test reading recipe by name
+	if err != nil {
+		t.Error(err)
+	}
+
+	rec2.Ingredients[0].Quantity = 10 // changing the copy should not change the database
+
+	rec3, _ := db.ReadRecipeByName("TestRecipe")
+	if rec3.Ingredients[0].Quantity != 1 {
+		t.Error("recipe in database was changed through a copy")
+	}
+
+	err = db.Delete(rec.ID, RecipeCollection) // test deleting recipe
+	if err != nil {
+		t.Error(err)
+	}
+
+	recipes, _ := db.ReadAllRecipes()
+	if len(recipes) != 0 {
+		t.Error("recipe was not deleted")
+	}
+
+	err = db.Delete("", WebhooksCollection) // test deleting something that dont exist, error is supposed to be sent
+	if err == nil {
+		t.Error("deleted a webhook that does not exist")
+	}
+
+	ok, err := db.CheckToken("testtoken") // test valid token
+	if !ok || err != nil {
+		t.Error("valid token was not accepted", err)
+	}
+
+	ok, _ = db.CheckToken("wrongtoken") // test invalid token
+	if ok {
+		t.Error("invalid token was accepted")
+	}
+
+	fmt.Println("testing MemoryDatabase")
+}
+
+func TestMemoryDatabaseConcurrent(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ { // save and read from many goroutines at once
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			wh := Webhook{Event: "test"}
+			_ = db.SaveWebhook(&wh)
+			_, _ = db.ReadAllWebhooks()
+		}()
+	}
+
+	wg.Wait()
+
+	webhooks, _ := db.ReadAllWebhooks()
+	if len(webhooks) != 50 {
+		t.Error("expected 50 webhooks, got", len(webhooks))
+	}
+}
@@ -0,0 +1,153 @@
+package cravings
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// NormalizeName returns the name ingredient names are compared by: lowercase, single spaces and the last word
+// in singular, so "Chopped  Tomatoes" and "chopped tomato" are the same
+func NormalizeName(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return ""
+	}
+
+	words[len(words)-1] = singular(words[len(words)-1])
+
+	return strings.Join(words, " ")
+}
+
+// singular returns the singular of an english word. It only has to give the same word for the singular and
+// plural of an ingredient, so words ending in "ie" end in "y" in both, i.e. "cookie" and "cookies" are "cooky"
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4: // berries
+		word = strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"):
+		word = strings.TrimSuffix(word, "es") // tomatoes, peaches, radishes
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") &&
+		len(word) > 2:
+		word = strings.TrimSuffix(word, "s") // eggs
+	}
+
+	if strings.HasSuffix(word, "ie") { // cookie, so it is the same as cookies
+		word = strings.TrimSuffix(word, "ie") + "y"
+	}
+
+	return word
+}
+
+// NormalizeAliases returns the aliases lowercase and trimmed, without empty ones, duplicates and the name itself
+func NormalizeAliases(name string, aliases []string) []string {
+	seen := map[string]bool{NormalizeName(name): true}
+
+	var normalized []string
+
+	for _, alias := range aliases {
+		alias = strings.Join(strings.Fields(strings.ToLower(alias)), " ")
+		if alias == "" || seen[NormalizeName(alias)] {
+			continue
+		}
+
+		seen[NormalizeName(alias)] = true
+		normalized = append(normalized, alias)
+	}
+
+	return normalized
+}
+
+// HasName returns true if name is the name or one of the aliases of the ingredient, after normalising them
+func (ing Ingredient) HasName(name string) bool {
+	name = NormalizeName(name)
+
+	if NormalizeName(ing.Name) == name {
+		return true
+	}
+
+	for _, alias := range ing.Aliases {
+		if NormalizeName(alias) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindIngredient returns the ingredient with the given name or alias. An ingredient with the name is found
+// before one with it as an alias
+func FindIngredient(name string, ingredients []Ingredient) (Ingredient, bool) {
+	for _, ing := range ingredients {
+		if NormalizeName(ing.Name) == NormalizeName(name) {
+			return ing, true
+		}
+	}
+
+	for _, ing := range ingredients {
+		if ing.HasName(name) {
+			return ing, true
+		}
+	}
+
+	return Ingredient{}, false
+}
+
+// DBFindIngredient reads the ingredient with the given name or alias from the ingredient cache, see NormalizeName for
+// how names are compared. If the cache does not have it, the ingredient is read by its exact name from the database in
+// case the cache has not seen it yet. If it is not found the error is a *NotFoundError with the closest ingredient names
+func DBFindIngredient(name string, w http.ResponseWriter) (Ingredient, error) {
+	ing, err := ingredientCache.Find(name, w)
+	if _, ok := notFound(err); !ok {
+		return ing, err
+	}
+
+	ing, dbErr := DBReadIngredientByName(name, w)
+	if _, ok := notFound(dbErr); ok {
+		return Ingredient{}, err // the error from the cache has the suggestions
+	}
+
+	return ing, dbErr
+}
+
+// nameIndex has the ids of the documents with every name, sorted so the one with the lowest id is found by name
+// like in firestore and bolt. A name can be used by more than one document
+type nameIndex map[string][]string
+
+// add adds the id to the ids with the name
+func (index nameIndex) add(name string, id string) {
+	ids := index[name]
+
+	i := sort.SearchStrings(ids, id)
+	if i < len(ids) && ids[i] == id { // i.e. an alias given twice
+		return
+	}
+
+	index[name] = append(ids[:i], append([]string{id}, ids[i:]...)...)
+}
+
+// remove takes the id out of the ids with the name, so the name is read from the next document with it
+func (index nameIndex) remove(name string, id string) {
+	ids := index[name]
+
+	i := sort.SearchStrings(ids, id)
+	if i == len(ids) || ids[i] != id {
+		return
+	}
+
+	if len(ids) == 1 {
+		delete(index, name)
+	} else {
+		index[name] = append(ids[:i], ids[i+1:]...)
+	}
+}
+
+// first returns the lowest id with the name, or "" if there is none
+func (index nameIndex) first(name string) string {
+	if ids := index[name]; len(ids) > 0 {
+		return ids[0]
+	}
+
+	return ""
+}
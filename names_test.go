@@ -0,0 +1,79 @@
+package cravings
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	for _, names := range [][2]string{
+		{"Tomatoes", "tomato"},
+		{"cherry  tomatoes", "cherry tomato"},
+		{"berries", "berry"},
+		{"cookies", "cookie"},
+		{"peaches", "peach"},
+		{"eggs", "egg"},
+		{"olives", "olive"},
+		{"hummus", "hummus"},
+		{"swiss cheese", "swiss cheese"},
+	} {
+		if NormalizeName(names[0]) != NormalizeName(names[1]) {
+			t.Error("expected \""+names[0]+"\" to be the same as \""+names[1]+"\", got",
+				NormalizeName(names[0]), NormalizeName(names[1]))
+		}
+	}
+
+	if NormalizeName("pea") == NormalizeName("peach") {
+		t.Error("pea and peach are the same")
+	}
+
+	fmt.Println("testing NormalizeName")
+}
+
+func TestFindIngredient(t *testing.T) {
+	ingredients := []Ingredient{
+		{Name: "zucchini", Aliases: []string{"courgette"}},
+		{Name: "courgette flower"},
+		{Name: "tomato"},
+	}
+
+	for name, expected := range map[string]string{"Courgettes": "zucchini", "TOMATOES": "tomato",
+		"courgette flowers": "courgette flower"} {
+		ing, found := FindIngredient(name, ingredients)
+		if !found || ing.Name != expected {
+			t.Error("expected "+expected+" for "+name+", got", ing.Name, found)
+		}
+	}
+
+	_, found := FindIngredient("potato", ingredients)
+	if found {
+		t.Error("found ingredient that is not in the list")
+	}
+
+	aliases := NormalizeAliases("zucchini", []string{" Courgette ", "courgettes", "", "Zucchinis"})
+	if len(aliases) != 1 || aliases[0] != "courgette" {
+		t.Error("expected only courgette as alias, got", aliases)
+	}
+}
+
+func TestDBFindIngredient(t *testing.T) {
+	ing := Ingredient{Name: "testaubergine", Unit: "g", Quantity: 1, Aliases: []string{"testeggplant"}}
+
+	err := DBSaveIngredient(&ing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBDelete(ing.ID, IngredientCollection, nil)
+
+	for _, name := range []string{"testaubergine", "testaubergines", "TestEggplants"} {
+		found, err := DBFindIngredient(name, nil)
+		if err != nil || found.ID != ing.ID {
+			t.Error("could not find ingredient by "+name, err)
+		}
+	}
+
+	_, err = DBFindIngredient("testnothing", nil) // error is supposed to be sent
+	if err == nil {
+		t.Error("found ingredient that is not in the database")
+	}
+}
@@ -0,0 +1,287 @@
+package cravings
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NutritionProvider gets the nutritional info for one unit of an ingredient, i.e. 1 g, 1 l or 1 tablespoon.
+// It fills in Calories, Weight and Nutrients of the ingredient
+type NutritionProvider interface {
+	GetNutrients(ing *Ingredient) error
+}
+
+// EdamamProvider gets nutritional info from the Edamam nutrition-data API
+type EdamamProvider struct {
+	URL     string
+	Client  *http.Client
+	Quota   *Quota        // Calls left to Edamam, shared with the status endpoint
+	Retries int           // How many times to retry after a 429 or 5xx response
+	Backoff time.Duration // Wait before first retry, doubled for each retry
+}
+
+// LocalNutritionProvider gets nutritional info from a table loaded from a JSON or CSV file
+type LocalNutritionProvider struct {
+	table map[string]Ingredient // nutrients for one unit, keyed by name and unit
+}
+
+// edamamQuota is the quota for all calls to Edamam
+var edamamQuota = NewQuota(EdamamCallsPerMinute, EdamamCallsPerDay)
+
+// Nutrition is the provider used by GetNutrients, Edamam by default
+var Nutrition NutritionProvider = NewEdamamProvider()
+
+// NewEdamamProvider returns an Edamam provider using the shared Edamam quota
+func NewEdamamProvider() *EdamamProvider {
+	return &EdamamProvider{URL: URLNutritionData, Client: OutboundClient(OutboundEdamam), Quota: edamamQuota,
+		Retries: EdamamRetries, Backoff: EdamamBackoff}
+}
+
+// SetEdamamQuota changes the number of calls allowed to Edamam
+func SetEdamamQuota(perMinute int, perDay int) {
+	edamamQuota.mu.Lock()
+	defer edamamQuota.mu.Unlock()
+
+	edamamQuota.PerMinute = perMinute
+	edamamQuota.PerDay = perDay
+}
+
+// SelectNutritionProvider sets Nutrition to the provider with the given name, either "edamam" or "local".
+// The local provider is loaded from NutrientFile. An empty name selects Edamam
+func SelectNutritionProvider(name string) error {
+	switch strings.ToLower(name) {
+	case "", NutritionEdamam:
+		Nutrition = NewEdamamProvider()
+	case NutritionLocal:
+		provider, err := NewLocalNutritionProvider(NutrientFile)
+		if err != nil {
+			return err
+		}
+
+		Nutrition = provider
+	default:
+		return errors.New("Unknown nutrition provider " + name)
+	}
+
+	return nil
+}
+
+// GetNutrients gets nutritional info for one unit of the ingredient from the selected provider
+func GetNutrients(ing *Ingredient, w http.ResponseWriter) error {
+	return Nutrition.GetNutrients(ing)
+}
+
+// edamamQuery returns the ingredient line sent to Edamam, i.e. "olive oil tablespoon"
+func edamamQuery(ing *Ingredient) string {
+	if ing.Unit == "pc" {
+		return ing.Name + " piece"
+	}
+
+	return ing.Name + " " + ing.Unit
+}
+
+// GetNutrients gets nutritional info from the Edamam API for the ingredient.
+// 429 and 5xx responses are retried with exponential backoff, as long as there is quota left
+func (p *EdamamProvider) GetNutrients(ing *Ingredient) error {
+	query := url.Values{}
+	query.Set("app_id", AppID)
+	query.Set("app_key", AppKey)
+	query.Set("ingr", edamamQuery(ing))
+
+	for retry := 0; ; retry++ {
+		if p.Quota != nil {
+			if err := p.Quota.Take(); err != nil {
+				return err // fail fast, do not wait for the quota to come back
+			}
+		}
+
+		resp, err := DoRequest(p.URL+"?"+query.Encode(), p.Client)
+		wait := p.Backoff << uint(retry)
+
+		if err == nil {
+			if resp.StatusCode == http.StatusOK {
+				defer resp.Body.Close()
+
+				err = json.NewDecoder(resp.Body).Decode(ing)
+				if err != nil {
+					return errors.Wrap(err, "Could not decode response body from Edamam")
+				}
+
+				return nil
+			}
+
+			resp.Body.Close()
+			err = errors.New("Edamam responded " + resp.Status)
+
+			// 555 is Edamam not understanding the ingredient, that will not change by retrying
+			if resp.StatusCode != http.StatusTooManyRequests &&
+				(resp.StatusCode < 500 || resp.StatusCode == 555) {
+				return err
+			}
+
+			if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil {
+				wait = time.Duration(seconds) * time.Second
+			}
+
+			if wait > EdamamMaxWait { // do not hold the request for longer than that
+				return errors.Wrap(ErrQuotaExhausted, "Edamam asked to wait "+wait.String())
+			}
+		}
+
+		if isCircuitOpen(err) || retry >= p.Retries { // no point retrying while the breaker is open
+			return errors.Wrap(err, "Giving up after "+strconv.Itoa(retry+1)+" calls to Edamam")
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+// nutrientKey is the key for an ingredient in the local nutrient table
+func nutrientKey(name string, unit string) string {
+	return strings.ToLower(name) + "|" + unit
+}
+
+// NewTotalNutrients returns TotalNutrients with the same labels and units as Edamam uses
+func NewTotalNutrients(energy, fat, carbohydrate, sugar, protein float64) TotalNutrients {
+	return TotalNutrients{
+		Energy:       Nutrient{Label: "Energy", Quantity: energy, Unit: "kcal"},
+		Fat:          Nutrient{Label: "Fat", Quantity: fat, Unit: "g"},
+		Carbohydrate: Nutrient{Label: "Carbs", Quantity: carbohydrate, Unit: "g"},
+		Sugar:        Nutrient{Label: "Sugars", Quantity: sugar, Unit: "g"},
+		Protein:      Nutrient{Label: "Protein", Quantity: protein, Unit: "g"},
+	}
+}
+
+// NewLocalNutritionProvider loads the nutrient table from a .json or .csv file.
+// The JSON file is a list of ingredients, the same as GET /cravings/food/ingredient returns.
+// The CSV file has the header name,unit,calories,weight,fat,protein,carbohydrate,sugar.
+// Every row is the nutrients for 1 of the unit
+func NewLocalNutritionProvider(file string) (*LocalNutritionProvider, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not open nutrient file")
+	}
+	defer f.Close()
+
+	var ingredients []Ingredient
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&ingredients)
+	case ".csv":
+		ingredients, err = readNutrientCSV(f)
+	default:
+		err = errors.New("Nutrient file has to be .json or .csv: " + file)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLocalNutritionProviderFrom(ingredients), nil
+}
+
+// NewLocalNutritionProviderFrom returns a local provider with the given ingredients as the nutrient table
+func NewLocalNutritionProviderFrom(ingredients []Ingredient) *LocalNutritionProvider {
+	p := &LocalNutritionProvider{table: map[string]Ingredient{}}
+
+	for _, i := range ingredients {
+		if i.Quantity == 0 {
+			i.Quantity = 1 // rows are for 1 of the unit if quantity is left out
+		}
+
+		p.table[nutrientKey(i.Name, i.Unit)] = i
+	}
+
+	return p
+}
+
+// readNutrientCSV reads the nutrient table from a CSV file with a header row
+func readNutrientCSV(r io.Reader) ([]Ingredient, error) {
+	var ingredients []Ingredient
+
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return ingredients, errors.Wrap(err, "Could not read nutrient CSV")
+	}
+
+	if len(rows) == 0 {
+		return ingredients, nil
+	}
+
+	columns := map[string]int{} // position of each column from the header
+
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, c := range []string{"name", "unit", "calories", "weight", "fat", "protein", "carbohydrate", "sugar"} {
+		if _, ok := columns[c]; !ok {
+			return ingredients, errors.New("Nutrient CSV is missing column " + c)
+		}
+	}
+
+	for n, row := range rows[1:] {
+		values := map[string]float64{}
+
+		for _, c := range []string{"calories", "weight", "fat", "protein", "carbohydrate", "sugar"} {
+			values[c], err = strconv.ParseFloat(strings.TrimSpace(row[columns[c]]), 64)
+			if err != nil {
+				return ingredients, errors.New("Nutrient CSV line " + strconv.Itoa(n+2) + ": " + c + " is not a number")
+			}
+		}
+
+		ingredients = append(ingredients, Ingredient{
+			Name:     strings.TrimSpace(row[columns["name"]]),
+			Unit:     strings.TrimSpace(row[columns["unit"]]),
+			Quantity: 1,
+			Calories: values["calories"],
+			Weight:   values["weight"],
+			Nutrients: NewTotalNutrients(values["calories"], values["fat"], values["carbohydrate"],
+				values["sugar"], values["protein"]),
+		})
+	}
+
+	return ingredients, nil
+}
+
+// GetNutrients looks up the ingredient in the nutrient table.
+// If there is no row for the unit, the row for the base unit of its dimension is converted, i.e. "g" for "kg"
+func (p *LocalNutritionProvider) GetNutrients(ing *Ingredient) error {
+	row, ok := p.table[nutrientKey(ing.Name, ing.Unit)]
+
+	if !ok {
+		converted := Ingredient{Quantity: 1, Unit: ing.Unit}
+		base, err := BaseUnit(ing.Unit)
+
+		if err == nil {
+			err = ConvertUnit(&converted, base)
+		}
+
+		row, ok = p.table[nutrientKey(ing.Name, converted.Unit)]
+		if err != nil || !ok || converted.Unit == ing.Unit {
+			return errors.New("No nutrients for " + ing.Name + " in " + ing.Unit + " in the nutrient table")
+		}
+
+		row, err = CalcRemaining(converted, row, false) // nutrients for 1 of the requested unit
+		if err != nil {
+			return err
+		}
+	}
+
+	ing.Calories = row.Calories
+	ing.Weight = row.Weight
+	ing.Nutrients = row.Nutrients
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package cravings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// nutrientCacheEntry is the nutrients for one unit of an ingredient and when they were fetched
+type nutrientCacheEntry struct {
+	Calories  float64        `json:"calories"`
+	Weight    float64        `json:"totalWeight"`
+	Nutrients TotalNutrients `json:"totalNutrients"`
+	Time      time.Time      `json:"time"`
+}
+
+// CachedNutritionProvider caches the responses of another provider, keyed by ingredient name and unit,
+// and saves the cache to a file so it survives restarts
+type CachedNutritionProvider struct {
+	Provider NutritionProvider
+	TTL      time.Duration // How long an entry is used before asking Provider again
+	File     string        // File the cache is saved to, not saved if empty
+
+	mu      sync.Mutex
+	entries map[string]nutrientCacheEntry
+}
+
+// NewCachedNutritionProvider returns a cache in front of provider, loaded from file if it exists
+func NewCachedNutritionProvider(provider NutritionProvider, file string, ttl time.Duration) (*CachedNutritionProvider, error) {
+	c := &CachedNutritionProvider{Provider: provider, TTL: ttl, File: file, entries: map[string]nutrientCacheEntry{}}
+
+	if file == "" {
+		return c, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) { // no cache saved yet
+		return c, nil
+	}
+
+	if err != nil {
+		return c, errors.Wrap(err, "Could not read nutrient cache")
+	}
+
+	err = json.Unmarshal(data, &c.entries)
+	if err != nil {
+		return c, errors.Wrap(err, "Could not decode nutrient cache")
+	}
+
+	return c, nil
+}
+
+// GetNutrients returns the cached nutrients if they are newer than TTL, else gets them from Provider.
+// If Provider fails, an expired entry is used rather than failing the request
+func (c *CachedNutritionProvider) GetNutrients(ing *Ingredient) error {
+	key := nutrientKey(ing.Name, ing.Unit)
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	c.mu.Unlock()
+
+	if found && time.Since(entry.Time) < c.TTL {
+		entry.apply(ing)
+		return nil
+	}
+
+	err := c.Provider.GetNutrients(ing)
+	if err != nil {
+		if found {
+			fmt.Println("Using expired nutrients for " + key + ": " + err.Error())
+			entry.apply(ing)
+
+			return nil
+		}
+
+		return err
+	}
+
+	if ing.Nutrients.Energy.Label == "" { // Edamam did not know the ingredient, do not cache that
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = nutrientCacheEntry{Calories: ing.Calories, Weight: ing.Weight, Nutrients: ing.Nutrients,
+		Time: time.Now()}
+
+	err = c.save()
+	if err != nil { // the nutrients are still good even if the cache could not be saved
+		fmt.Println(err.Error())
+	}
+
+	return nil
+}
+
+// apply sets the cached nutrients on the ingredient
+func (e nutrientCacheEntry) apply(ing *Ingredient) {
+	ing.Calories = e.Calories
+	ing.Weight = e.Weight
+	ing.Nutrients = e.Nutrients
+}
+
+// Invalidate removes every cached unit of the ingredient with the given name
+func (c *CachedNutritionProvider) Invalidate(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := nutrientKey(name, "")
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+
+	return c.save()
+}
+
+// InvalidateAll empties the cache
+func (c *CachedNutritionProvider) InvalidateAll() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]nutrientCacheEntry{}
+
+	return c.save()
+}
+
+// save writes the cache to File, c.mu has to be locked by the caller
+func (c *CachedNutritionProvider) save() error {
+	if c.File == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash does not leave half a cache behind
+	err = ioutil.WriteFile(c.File+".tmp", data, 0600)
+	if err != nil {
+		return errors.Wrap(err, "Could not save nutrient cache")
+	}
+
+	return os.Rename(c.File+".tmp", c.File)
+}
+
+// EnableNutrientCache puts a cache saved to file in front of the selected nutrition provider
+func EnableNutrientCache(file string, ttl time.Duration) error {
+	cache, err := NewCachedNutritionProvider(Nutrition, file, ttl)
+	if err != nil {
+		return err
+	}
+
+	Nutrition = cache
+
+	return nil
+}
+
+// InvalidateNutrients removes the ingredient from the nutrient cache, if the cache is enabled
+func InvalidateNutrients(name string) error {
+	if cache, ok := Nutrition.(*CachedNutritionProvider); ok {
+		return cache.Invalidate(name)
+	}
+
+	return nil
+}
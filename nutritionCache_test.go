@@ -0,0 +1,105 @@
+package cravings
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingProvider counts the calls to it, and fails if fail is set
+type countingProvider struct {
+	calls int
+	fail  bool
+}
+
+func (p *countingProvider) GetNutrients(ing *Ingredient) error {
+	p.calls++
+
+	if p.fail {
+		return errors.New("provider is down")
+	}
+
+	ing.Calories = 119
+	ing.Nutrients = NewTotalNutrients(119, 13.5, 0, 0, 0)
+
+	return nil
+}
+
+func TestCachedNutritionProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cravings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "cache.json")
+	provider := &countingProvider{}
+
+	cache, err := NewCachedNutritionProvider(provider, file, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ { // only the first lookup should reach the provider
+		ing := Ingredient{Name: "olive oil", Unit: "tablespoon"}
+
+		err = cache.GetNutrients(&ing)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if ing.Calories != 119 {
+			t.Error("wrong calories from cache", ing.Calories)
+		}
+	}
+
+	if provider.calls != 1 {
+		t.Error("expected 1 call to provider, got", provider.calls)
+	}
+
+	// a new cache loaded from the same file should not call the provider either
+	cache, err = NewCachedNutritionProvider(provider, file, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ing := Ingredient{Name: "olive oil", Unit: "tablespoon"}
+	_ = cache.GetNutrients(&ing)
+
+	if provider.calls != 1 || ing.Calories != 119 {
+		t.Error("cache was not loaded from file")
+	}
+
+	err = cache.Invalidate("olive oil") // test invalidation
+	if err != nil {
+		t.Error(err)
+	}
+
+	_ = cache.GetNutrients(&ing)
+
+	if provider.calls != 2 {
+		t.Error("invalidated entry was used")
+	}
+
+	// expired entry is used when the provider fails
+	cache.TTL = 0
+	provider.fail = true
+
+	err = cache.GetNutrients(&ing)
+	if err != nil || ing.Calories != 119 {
+		t.Error("expired entry was not used when provider failed", err)
+	}
+
+	ing = Ingredient{Name: "salt", Unit: "teaspoon"}
+
+	err = cache.GetNutrients(&ing) // nothing cached and the provider fails, error is supposed to be sent
+	if err == nil {
+		t.Error("expected error when provider fails without cached entry")
+	}
+
+	fmt.Println("testing CachedNutritionProvider")
+}
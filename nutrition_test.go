@@ -0,0 +1,157 @@
+package cravings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestEdamamProvider(t *testing.T) {
+	var query string
+
+	// test server answering like Edamam
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("ingr")
+		fmt.Fprintln(w, `{"calories":119,"totalWeight":13.5,"totalNutrients":{"ENERC_KCAL":{"label":"Energy","quantity":119,"unit":"kcal"}}}`)
+	}))
+	defer server.Close()
+
+	p := &EdamamProvider{URL: server.URL, Client: server.Client()}
+	ing := Ingredient{Name: "olive oil", Unit: "tablespoon"}
+
+	err := p.GetNutrients(&ing)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if query != "olive oil tablespoon" { // spaces should be encoded by the provider
+		t.Error("wrong query sent to Edamam: " + query)
+	}
+
+	if ing.Calories != 119 || ing.Nutrients.Energy.Label != "Energy" || ing.Name != "olive oil" {
+		t.Error("wrong nutrients", ing)
+	}
+
+	fmt.Println("testing EdamamProvider")
+}
+
+func TestEdamamProviderRetry(t *testing.T) {
+	calls := 0
+
+	// test server answering 429 and 503 before answering ok
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprintln(w, `{"calories":52,"totalNutrients":{"ENERC_KCAL":{"label":"Energy","quantity":52,"unit":"kcal"}}}`)
+		}
+	}))
+	defer server.Close()
+
+	p := &EdamamProvider{URL: server.URL, Client: server.Client(), Quota: NewQuota(10, 10),
+		Retries: 3, Backoff: time.Millisecond}
+	ing := Ingredient{Name: "apple", Unit: "pc"}
+
+	err := p.GetNutrients(&ing) // test retrying after 429 and 503
+	if err != nil {
+		t.Error(err)
+	}
+
+	if calls != 3 || ing.Calories != 52 {
+		t.Error("expected nutrients after 3 calls, got", calls, ing.Calories)
+	}
+
+	p.Quota = NewQuota(1, 10)
+	calls = 0
+
+	err = p.GetNutrients(&ing) // only one call left, the retry should fail fast on the quota
+	if errors.Cause(err) != ErrQuotaExhausted {
+		t.Error("expected quota to be exhausted, got", err)
+	}
+
+	if calls != 1 {
+		t.Error("expected 1 call before quota was exhausted, got", calls)
+	}
+
+	fmt.Println("testing EdamamProvider retry")
+}
+
+func TestLocalNutritionProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cravings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	csvFile := filepath.Join(dir, "nutrients.csv")
+	csvData := "name,unit,calories,weight,fat,protein,carbohydrate,sugar\n" +
+		"milk,l,640,1030,33,33,49,52\n" +
+		"sugar,g,3.87,1,0,0,1,1\n"
+
+	err = ioutil.WriteFile(csvFile, []byte(csvData), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewLocalNutritionProvider(csvFile) // test loading from CSV
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ing := Ingredient{Name: "Milk", Unit: "dl"}
+
+	err = p.GetNutrients(&ing) // test converting from the l row
+	if err != nil {
+		t.Error(err)
+	}
+
+	if math.Abs(ing.Calories-64) > 1e-9 || math.Abs(ing.Nutrients.Fat.Quantity-3.3) > 1e-9 {
+		t.Error("wrong nutrients for 1 dl milk", ing)
+	}
+
+	ing = Ingredient{Name: "sugar", Unit: "tablespoon"}
+
+	err = p.GetNutrients(&ing) // no row for tablespoon, error is supposed to be sent
+	if err == nil {
+		t.Error("got nutrients for unit that is not in the table")
+	}
+
+	jsonFile := filepath.Join(dir, "nutrients.json")
+
+	err = ioutil.WriteFile(jsonFile, []byte(`[{"name":"salt","unit":"tablespoon","calories":0}]`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = NewLocalNutritionProvider(jsonFile) // test loading from JSON
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ing = Ingredient{Name: "salt", Unit: "tablespoon"}
+
+	err = p.GetNutrients(&ing)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = NewLocalNutritionProvider(filepath.Join(dir, "nutrients.txt")) // unknown file type
+	if err == nil {
+		t.Error("loaded nutrient file that is not .json or .csv")
+	}
+
+	fmt.Println("testing LocalNutritionProvider")
+}
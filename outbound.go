@@ -0,0 +1,257 @@
+package cravings
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen is returned by outbound clients while the circuit breaker of the destination is open
+var ErrCircuitOpen = errors.New("circuit breaker is open, destination has failed too many times")
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // calls go through
+	BreakerOpen     = "open"      // calls fail right away until the cooldown is over
+	BreakerHalfOpen = "half-open" // one trial call goes through to see if the destination is back
+)
+
+// CircuitBreaker stops calls to a destination after too many failures in a row
+type CircuitBreaker struct {
+	Failures int           // Failures in a row before the breaker opens
+	Cooldown time.Duration // How long the breaker stays open before a trial call
+
+	mu       sync.Mutex
+	state    string
+	failed   int       // failures in a row
+	openedAt time.Time // when the breaker opened
+	trial    bool      // a trial call is in progress
+}
+
+// breakerTransport is a http.RoundTripper that goes through a circuit breaker
+type breakerTransport struct {
+	breaker   *CircuitBreaker
+	transport http.RoundTripper
+}
+
+// outbound holds the client and breaker for each destination
+var outbound = struct {
+	mu       sync.Mutex
+	clients  map[string]*http.Client
+	breakers map[string]*CircuitBreaker
+}{clients: map[string]*http.Client{}, breakers: map[string]*CircuitBreaker{}}
+
+// NewCircuitBreaker returns a closed breaker opening after failures failures in a row
+func NewCircuitBreaker(failures int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Failures: failures, Cooldown: cooldown, state: BreakerClosed}
+}
+
+// Allow returns true if a call can be made now
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+
+		b.state = BreakerHalfOpen // cooldown is over, let one call through
+		b.trial = true
+
+		return true
+	case BreakerHalfOpen:
+		if b.trial { // only one trial call at a time
+			return false
+		}
+
+		b.trial = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+// Record registers the result of a call allowed by Allow
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+
+	if success {
+		b.state = BreakerClosed
+		b.failed = 0
+
+		return
+	}
+
+	b.failed++
+
+	if b.state == BreakerHalfOpen || b.failed >= b.Failures {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the state of the breaker and the failures in a row
+func (b *CircuitBreaker) State() (string, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.Cooldown {
+		return BreakerHalfOpen, b.failed
+	}
+
+	return b.state, b.failed
+}
+
+// Do makes the call if the breaker allows it, and counts errors and 5xx responses as failures
+func (b *CircuitBreaker) Do(call func() (*http.Response, error)) (*http.Response, error) {
+	if !b.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := call()
+
+	b.Record(err == nil && !serverFailure(resp.StatusCode))
+
+	return resp, err
+}
+
+// RoundTrip does the call through the breaker of the transport
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.breaker.Do(func() (*http.Response, error) {
+		return t.transport.RoundTrip(req)
+	})
+}
+
+// serverFailure returns true for the status codes that mean the server is having trouble
+func serverFailure(code int) bool {
+	return code == http.StatusInternalServerError || code == http.StatusBadGateway ||
+		code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// OutboundClient returns the http client for a destination, with the timeout of the destination and its
+// own circuit breaker. Destinations without a timeout in OutboundTimeouts use the webhook timeout
+func OutboundClient(destination string) *http.Client {
+	outbound.mu.Lock()
+	defer outbound.mu.Unlock()
+
+	if client, ok := outbound.clients[destination]; ok {
+		return client
+	}
+
+	timeout, ok := OutboundTimeouts[destination]
+	if !ok {
+		timeout = OutboundTimeouts[OutboundWebhook]
+	}
+
+	breaker := NewCircuitBreaker(BreakerFailures, BreakerCooldown)
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &breakerTransport{breaker: breaker, transport: http.DefaultTransport},
+	}
+
+	outbound.clients[destination] = client
+	outbound.breakers[destination] = breaker
+
+	return client
+}
+
+// SetOutboundTimeout changes the timeout for calls to a destination
+func SetOutboundTimeout(destination string, timeout time.Duration) {
+	outbound.mu.Lock()
+	defer outbound.mu.Unlock()
+
+	OutboundTimeouts[destination] = timeout
+
+	if client, ok := outbound.clients[destination]; ok {
+		client.Timeout = timeout
+	}
+}
+
+// webhookBreakerPrefix is the start of the destination of a webhook's circuit breaker, followed by the webhook ID
+const webhookBreakerPrefix = OutboundWebhook + " "
+
+// PostWebhook posts body to the url of the webhook. Every webhook uses the same client, with the webhook timeout,
+// and has its own circuit breaker, so one broken webhook does not stop calls to the others
+func PostWebhook(wh Webhook, body []byte) (*http.Response, error) {
+	outbound.mu.Lock()
+
+	client, ok := outbound.clients[OutboundWebhook]
+	if !ok {
+		client = &http.Client{Timeout: OutboundTimeouts[OutboundWebhook]}
+		outbound.clients[OutboundWebhook] = client
+	}
+
+	breaker, ok := outbound.breakers[webhookBreakerPrefix+wh.ID]
+	if !ok {
+		breaker = NewCircuitBreaker(BreakerFailures, BreakerCooldown)
+		outbound.breakers[webhookBreakerPrefix+wh.ID] = breaker
+	}
+
+	outbound.mu.Unlock()
+
+	return breaker.Do(func() (*http.Response, error) {
+		return client.Post(wh.URL, "json", bytes.NewReader(body))
+	})
+}
+
+// RemoveWebhookBreaker forgets the circuit breaker of a deleted webhook
+func RemoveWebhookBreaker(id string) {
+	outbound.mu.Lock()
+	defer outbound.mu.Unlock()
+
+	delete(outbound.breakers, webhookBreakerPrefix+id)
+}
+
+// BreakerState returns the state of the circuit breaker of a destination, closed if it has not been called
+func BreakerState(destination string) string {
+	outbound.mu.Lock()
+	breaker, ok := outbound.breakers[destination]
+	outbound.mu.Unlock()
+
+	if !ok {
+		return BreakerClosed
+	}
+
+	state, _ := breaker.State()
+
+	return state
+}
+
+// BreakerStatuses returns the state of the circuit breaker of every destination called so far
+func BreakerStatuses() []BreakerStatus {
+	outbound.mu.Lock()
+	defer outbound.mu.Unlock()
+
+	statuses := []BreakerStatus{}
+
+	for destination, breaker := range outbound.breakers {
+		state, failures := breaker.State()
+		statuses = append(statuses, BreakerStatus{Destination: destination, State: state, Failures: failures})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Destination < statuses[j].Destination
+	})
+
+	return statuses
+}
+
+// isCircuitOpen returns true if err is caused by an open circuit breaker
+func isCircuitOpen(err error) bool {
+	if urlErr, ok := errors.Cause(err).(*url.Error); ok {
+		return urlErr.Err == ErrCircuitOpen
+	}
+
+	return errors.Cause(err) == ErrCircuitOpen
+}
@@ -0,0 +1,91 @@
+package cravings
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker(2, time.Hour)
+
+	b.Record(false)
+
+	if state, _ := b.State(); state != BreakerClosed { // one failure is not enough to open
+		t.Error("expected closed breaker, got", state)
+	}
+
+	b.Record(false)
+
+	if b.Allow() { // two failures in a row opens the breaker
+		t.Error("open breaker allowed a call")
+	}
+
+	b.Cooldown = 0 // cooldown is over, one trial call is allowed
+
+	if !b.Allow() {
+		t.Error("breaker did not allow a trial call after cooldown")
+	}
+
+	if b.Allow() {
+		t.Error("breaker allowed a second call during the trial")
+	}
+
+	b.Record(true) // trial call went well
+
+	if state, failures := b.State(); state != BreakerClosed || failures != 0 {
+		t.Error("expected closed breaker after successful trial, got", state, failures)
+	}
+
+	fmt.Println("testing CircuitBreaker")
+}
+
+func TestPostWebhook(t *testing.T) {
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	broken := Webhook{ID: "testbroken", URL: server.URL + "/hook"}
+
+	for i := 0; i < BreakerFailures+2; i++ {
+		resp, err := PostWebhook(broken, []byte("{}"))
+		if err == nil {
+			resp.Body.Close()
+		} else if !isCircuitOpen(err) {
+			t.Error(err)
+		}
+	}
+
+	if calls != BreakerFailures { // calls after the breaker opened should not reach the server
+		t.Error("expected", BreakerFailures, "calls to reach the server, got", calls)
+	}
+
+	if BreakerState(webhookBreakerPrefix+broken.ID) != BreakerOpen {
+		t.Error("open breaker was not in the statuses", BreakerStatuses())
+	}
+
+	resp, err := PostWebhook(Webhook{ID: "testother", URL: server.URL + "/hook"}, []byte("{}"))
+	if err != nil { // another webhook on the same host has its own breaker
+		t.Error(err)
+	} else {
+		resp.Body.Close()
+	}
+
+	RemoveWebhookBreaker(broken.ID)
+	RemoveWebhookBreaker("testother")
+
+	for _, status := range BreakerStatuses() {
+		if strings.HasPrefix(status.Destination, webhookBreakerPrefix) {
+			t.Error("breaker of a deleted webhook is still in the statuses", status)
+		}
+	}
+
+	fmt.Println("testing PostWebhook")
+}
@@ -0,0 +1,326 @@
+package cravings
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// number is a whole number, decimal, fraction or whole number with a fraction, i.e. "2", "2.5", "1/2" or "2 1/2"
+const number = `(?:\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?)`
+
+// quantityPattern is the quantity at the start of an ingredient line, a number or a range like "2-3" or "2 to 3"
+var quantityPattern = regexp.MustCompile(`^(` + number + `)(?:\s*(?:-|–|to)\s*(` + number + `))?`)
+
+// decimalComma is a decimal written with a comma, i.e. "2,5". Three digits after the comma are thousands, see
+// thousandsComma
+var decimalComma = regexp.MustCompile(`(\d),(\d{1,2})(\D|$)`)
+
+// thousandsComma is a comma between thousands, i.e. "1,000"
+var thousandsComma = regexp.MustCompile(`(\d),(\d{3})(\D|$)`)
+
+// parentheses is a remark in an ingredient line, i.e. "(optional)"
+var parentheses = regexp.MustCompile(`\([^)]*\)`)
+
+// unicodeFractions are fraction characters and the fraction they are read as
+var unicodeFractions = map[string]string{
+	"½": " 1/2", "⅓": " 1/3", "⅔": " 2/3", "¼": " 1/4", "¾": " 3/4", "⅛": " 1/8",
+}
+
+// unitAliases are the ways units are written in ingredient lines, and the unit in the registry they are
+var unitAliases = map[string]string{
+	"kilo": "kg", "kilos": "kg", "kilogram": "kg", "kilograms": "kg", "kgs": "kg",
+	"gram": "g", "grams": "g", "gr": "g",
+	"pound": "lb", "pounds": "lb", "lbs": "lb",
+	"ounce": "oz", "ounces": "oz",
+	"liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"deciliter": "dl", "deciliters": "dl", "decilitre": "dl", "decilitres": "dl",
+	"centiliter": "cl", "centiliters": "cl", "centilitre": "cl", "centilitres": "cl",
+	"milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"pints": "pint", "cups": "cup",
+	"fluid ounce": "fl oz", "fluid ounces": "fl oz", "floz": "fl oz",
+	"piece": "pc", "pieces": "pc", "pcs": "pc",
+	"tablespoons": "tablespoon", "tbsp": "tablespoon", "tbs": "tablespoon", "tbsps": "tablespoon",
+	"teaspoons": "teaspoon", "tsp": "teaspoon", "tsps": "teaspoon",
+	"pinches": "pinch",
+}
+
+// preparationWords describe how an ingredient is prepared, and are not part of its name
+var preparationWords = map[string]bool{
+	"chopped": true, "diced": true, "minced": true, "sliced": true, "grated": true, "peeled": true,
+	"melted": true, "softened": true, "crushed": true, "finely": true, "roughly": true, "thinly": true,
+	"fresh": true, "freshly": true, "large": true, "medium": true, "small": true,
+}
+
+// ItemError is the error of one ingredient in a list that could not be read
+type ItemError struct {
+	Item  int    // position in the list, from 1
+	Input string // the ingredient as it was given
+	Err   error
+}
+
+// ItemErrors are the errors of every ingredient in a list that could not be read
+type ItemErrors []ItemError
+
+// ItemCorrection is an ingredient in a list that was not found, and was read as the only ingredient close to it
+type ItemCorrection struct {
+	Item   int    `json:"item"`   // position in the list, from 1
+	Input  string `json:"input"`  // the name as it was given
+	ReadAs string `json:"readAs"` // the name of the ingredient it was read as
+}
+
+// Error lists the errors, one ingredient per line
+func (e ItemErrors) Error() string {
+	var lines []string
+
+	for _, item := range e {
+		lines = append(lines, "ingredient "+strconv.Itoa(item.Item)+" \""+item.Input+"\": "+item.Err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ParseIngredientLine reads an ingredient from a line of text like "2 1/2 cups chopped tomatoes" or "500g flour".
+// The quantity can be a fraction, and a range like "2-3" is read as the middle of it. Lines without a unit are
+// in pieces, i.e. "2 eggs"
+func ParseIngredientLine(line string) (Ingredient, error) {
+	ing := Ingredient{}
+
+	text := strings.ToLower(strings.TrimSpace(line))
+	for fraction, replacement := range unicodeFractions {
+		text = strings.Replace(text, fraction, replacement, -1)
+	}
+
+	// remarks after a comma or in parentheses are not part of the ingredient, i.e. "tomatoes, chopped"
+	text = decimalComma.ReplaceAllString(text, "$1.$2$3")
+	text = thousandsComma.ReplaceAllString(text, "$1$2$3")
+	text = parentheses.ReplaceAllString(text, " ")
+
+	if i := strings.Index(text, ","); i >= 0 {
+		text = text[:i]
+	}
+
+	text = strings.TrimSpace(text)
+
+	if match := quantityPattern.FindStringSubmatch(text); match != nil {
+		quantity, err := parseNumber(match[1])
+		if err != nil {
+			return ing, errors.Wrap(err, "Could not read quantity of \""+line+"\"")
+		}
+
+		if match[2] != "" { // range, use the middle of it
+			upper, err := parseNumber(match[2])
+			if err != nil {
+				return ing, errors.Wrap(err, "Could not read quantity of \""+line+"\"")
+			}
+
+			quantity = (quantity + upper) / 2
+		}
+
+		ing.Quantity = quantity
+		text = text[len(match[0]):]
+	}
+
+	words := strings.Fields(text)
+
+	if ing.Quantity == 0 && len(words) > 0 && (words[0] == "a" || words[0] == "an") { // "a pinch of salt"
+		ing.Quantity = 1
+		words = words[1:]
+	}
+
+	if ing.Quantity <= 0 {
+		return ing, errors.New("No quantity in \"" + line + "\", i.e. \"2 dl milk\"")
+	}
+
+	ing.Unit, words = parseUnit(words)
+
+	if len(words) > 0 && words[0] == "of" {
+		words = words[1:]
+	}
+
+	var name []string
+
+	for _, word := range words {
+		if !preparationWords[word] {
+			name = append(name, word)
+		}
+	}
+
+	ing.Name = strings.Join(name, " ")
+	if ing.Name == "" {
+		return ing, errors.New("No ingredient name in \"" + line + "\"")
+	}
+
+	return ing, nil
+}
+
+// parseNumber reads a whole number, decimal, fraction or whole number with a fraction
+func parseNumber(s string) (float64, error) {
+	parts := strings.Fields(s)
+	if len(parts) == 2 { // whole number with a fraction, i.e. "2 1/2"
+		whole, err := parseNumber(parts[0])
+		if err != nil {
+			return 0, err
+		}
+
+		fraction, err := parseNumber(parts[1])
+
+		return whole + fraction, err
+	}
+
+	if i := strings.Index(s, "/"); i >= 0 {
+		numerator, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, err
+		}
+
+		denominator, err := strconv.ParseFloat(s[i+1:], 64)
+		if err != nil {
+			return 0, err
+		}
+
+		if denominator == 0 {
+			return 0, errors.New("Can not divide by zero in " + s)
+		}
+
+		return numerator / denominator, nil
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseUnit reads the unit at the start of words, and returns it with the words after it.
+// The unit is "pc" if words does not start with a unit
+func parseUnit(words []string) (string, []string) {
+	for n := 2; n >= 1; n-- { // try units of two words first, i.e. "fl oz"
+		if len(words) < n {
+			continue
+		}
+
+		word := strings.TrimSuffix(strings.Join(words[:n], " "), ".") // i.e. "tbsp."
+
+		if unit, ok := unitAliases[word]; ok {
+			return unit, words[n:]
+		}
+
+		if _, err := LookupUnit(word); err == nil {
+			return word, words[n:]
+		}
+	}
+
+	return "pc", words
+}
+
+// UnmarshalJSON lets an ingredient be given as a line of text, i.e. "2 1/2 cups chopped tomatoes",
+// as well as an object
+func (ing *Ingredient) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var line string
+
+		err := json.Unmarshal(data, &line)
+		if err != nil {
+			return err
+		}
+
+		parsed, err := ParseIngredientLine(line)
+		if err != nil {
+			return err
+		}
+
+		*ing = parsed
+
+		return nil
+	}
+
+	type object Ingredient // same fields without this method, so it is decoded like any other struct
+
+	return json.Unmarshal(data, (*object)(ing))
+}
+
+// ReadMealQuery reads the ingredients of a meal from the query. Each ingredient is either in its own "ingredient"
+// parameter as a line of text, i.e. ?ingredient=2 dl milk&ingredient=500 g flour, or all of them are in
+// "ingredients" as a URL-encoded JSON list of ingredients or lines. The old syntax
+// ?ingredients=name|quantity|unit_... is still read. Every ingredient that can not be read is in the returned ItemErrors
+func ReadMealQuery(r *http.Request) ([]Ingredient, error) {
+	query := r.URL.Query()
+	lines := query["ingredient"]
+	list := strings.TrimSpace(query.Get("ingredients"))
+
+	if len(lines) == 0 && list == "" {
+		return []Ingredient{}, errors.New("No ingredients in query, i.e. ?ingredient=2 dl milk&ingredient=500 g flour")
+	}
+
+	ingredients := []Ingredient{}
+
+	var errs ItemErrors
+
+	for i, line := range lines {
+		ing, err := ParseIngredientLine(line)
+		if err != nil {
+			errs = append(errs, ItemError{Item: i + 1, Input: line, Err: err})
+			continue
+		}
+
+		ingredients = append(ingredients, ing)
+	}
+
+	var read []Ingredient // ingredients in "ingredients"
+
+	var err error
+
+	switch {
+	case list == "":
+	case strings.HasPrefix(list, "["): // JSON list
+		var raw []json.RawMessage
+
+		err = json.Unmarshal([]byte(list), &raw)
+		if err != nil {
+			return ingredients, errors.Wrap(err, "Could not read ingredients as a JSON list")
+		}
+
+		var listErrs ItemErrors
+
+		for i := range raw {
+			ing := Ingredient{}
+
+			itemErr := json.Unmarshal(raw[i], &ing)
+			if itemErr == nil {
+				itemErr = CheckIngredient(ing)
+			}
+
+			if itemErr != nil {
+				listErrs = append(listErrs, ItemError{Item: i + 1, Input: string(raw[i]), Err: itemErr})
+				continue
+			}
+
+			read = append(read, ing)
+		}
+
+		if len(listErrs) > 0 {
+			err = listErrs
+		}
+	default: // old syntax, ingredients separated by '_'
+		read, err = ReadIngredients(strings.Split(list, "_"), nil)
+	}
+
+	ingredients = append(ingredients, read...)
+
+	if listErrs, ok := err.(ItemErrors); ok {
+		for _, e := range listErrs { // number the items after the "ingredient" parameters
+			e.Item += len(lines)
+			errs = append(errs, e)
+		}
+	} else if err != nil {
+		return ingredients, err
+	}
+
+	if len(errs) > 0 {
+		return ingredients, errs
+	}
+
+	return ingredients, nil
+}
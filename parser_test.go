@@ -0,0 +1,122 @@
+package cravings
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseIngredientLine(t *testing.T) {
+	for _, c := range []struct {
+		line     string
+		quantity float64
+		unit     string
+		name     string
+	}{
+		{"2 1/2 cups chopped tomatoes", 2.5, "cup", "tomatoes"},
+		{"500g flour", 500, "g", "flour"},
+		{"2,5 dl Milk", 2.5, "dl", "milk"},
+		{"2,25dl milk", 2.25, "dl", "milk"},
+		{"1,000 g flour", 1000, "g", "flour"},
+		{"1,500g sugar, sifted", 1500, "g", "sugar"},
+		{"1/2 tsp. salt", 0.5, "teaspoon", "salt"},
+		{"2-3 tbsp olive oil", 2.5, "tablespoon", "olive oil"},
+		{"1 to 2 fl oz cream (optional)", 1.5, "fl oz", "cream"},
+		{"3 large eggs", 3, "pc", "eggs"},
+		{"a pinch of salt", 1, "pinch", "salt"},
+		{"½ kg potatoes, peeled", 0.5, "kg", "potatoes"},
+		{"1 ½ lbs ground beef", 1.5, "lb", "ground beef"},
+	} {
+		ing, err := ParseIngredientLine(c.line)
+		if err != nil {
+			t.Error(c.line, err)
+			continue
+		}
+
+		if math.Abs(ing.Quantity-c.quantity) > 1e-9 || ing.Unit != c.unit || ing.Name != c.name {
+			t.Error("expected", c.quantity, c.unit, c.name, "from \""+c.line+"\", got", ing.Quantity, ing.Unit, ing.Name)
+		}
+	}
+
+	for _, line := range []string{"", "salt", "2 cups", "1/0 cup milk"} { // errors are supposed to be sent
+		_, err := ParseIngredientLine(line)
+		if err == nil {
+			t.Error("parsed \"" + line + "\"")
+		}
+	}
+
+	fmt.Println("testing ParseIngredientLine")
+}
+
+func TestUnmarshalIngredientLine(t *testing.T) {
+	rec := Recipe{}
+
+	err := json.Unmarshal([]byte(`{"recipeName":"test","ingredients":["2 dl milk",{"name":"flour","quantity":1,"unit":"kg"}]}`), &rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.Ingredients) != 2 || rec.Ingredients[0].Name != "milk" || rec.Ingredients[0].Unit != "dl" ||
+		rec.Ingredients[1].Name != "flour" || rec.Ingredients[1].Quantity != 1 {
+		t.Error("wrong ingredients in recipe", rec.Ingredients)
+	}
+
+	err = json.Unmarshal([]byte(`{"ingredients":["milk"]}`), &rec) // no quantity, error is supposed to be sent
+	if err == nil {
+		t.Error("unmarshaled ingredient line without quantity")
+	}
+}
+
+func TestReadMealQuery(t *testing.T) {
+	query := url.Values{}
+	query.Add("ingredient", "2 dl milk")
+	query.Add("ingredient", "1 kg pasta_shells") // underscores in names are kept
+
+	r := httptest.NewRequest(http.MethodGet, "/cravings/meal/?"+query.Encode(), nil)
+
+	ingredients, err := ReadMealQuery(r)
+	if err != nil || len(ingredients) != 2 || ingredients[1].Name != "pasta_shells" {
+		t.Error("could not read ingredient parameters", ingredients, err)
+	}
+
+	query = url.Values{}
+	query.Set("ingredients", `[{"name":"milk","quantity":2,"unit":"l"},"500 g flour"]`)
+
+	r = httptest.NewRequest(http.MethodGet, "/cravings/meal/?"+query.Encode(), nil)
+
+	ingredients, err = ReadMealQuery(r)
+	if err != nil || len(ingredients) != 2 || ingredients[1].Name != "flour" || ingredients[1].Quantity != 500 {
+		t.Error("could not read JSON list", ingredients, err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/cravings/meal/?ingredients=milk|2|l_olive%20oil|1|l", nil)
+
+	ingredients, err = ReadMealQuery(r) // old syntax
+	if err != nil || len(ingredients) != 2 || ingredients[1].Name != "olive oil" {
+		t.Error("could not read old syntax", ingredients, err)
+	}
+
+	query = url.Values{}
+	query.Add("ingredient", "salt") // no quantity
+	query.Set("ingredients", `[{"name":"milk","unit":"l"}]`)
+
+	r = httptest.NewRequest(http.MethodGet, "/cravings/meal/?"+query.Encode(), nil)
+
+	_, err = ReadMealQuery(r)
+
+	errs, ok := err.(ItemErrors)
+	if !ok || len(errs) != 2 || errs[0].Item != 1 || errs[1].Item != 2 {
+		t.Error("expected errors for item 1 and 2, got", err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/cravings/meal/", nil)
+
+	_, err = ReadMealQuery(r)
+	if err == nil {
+		t.Error("read query without ingredients")
+	}
+}
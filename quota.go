@@ -0,0 +1,88 @@
+package cravings
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQuotaExhausted is the cause of the error returned when there are no calls left to an external API
+var ErrQuotaExhausted = errors.New("Edamam quota exhausted")
+
+// Quota counts the calls made to an external API per minute and per day. A limit of 0 means no limit
+type Quota struct {
+	PerMinute int
+	PerDay    int
+
+	mu          sync.Mutex
+	minute      time.Time // start of the current minute
+	minuteCalls int
+	day         time.Time // start of the current day
+	dayCalls    int
+}
+
+// NewQuota returns a quota allowing perMinute calls each minute and perDay calls each day
+func NewQuota(perMinute int, perDay int) *Quota {
+	return &Quota{PerMinute: perMinute, PerDay: perDay}
+}
+
+// reset starts new counting periods if the current ones are over, q.mu has to be locked by the caller
+func (q *Quota) reset(now time.Time) {
+	if now.Sub(q.minute) >= time.Minute {
+		q.minute = now
+		q.minuteCalls = 0
+	}
+
+	if now.Sub(q.day) >= 24*time.Hour {
+		q.day = now
+		q.dayCalls = 0
+	}
+}
+
+// Take uses one call of the quota, or returns an error caused by ErrQuotaExhausted if there are none left
+func (q *Quota) Take() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	q.reset(now)
+
+	if q.PerDay > 0 && q.dayCalls >= q.PerDay {
+		wait := q.day.Add(24 * time.Hour).Sub(now)
+		return errors.Wrap(ErrQuotaExhausted, "Daily limit of "+strconv.Itoa(q.PerDay)+
+			" calls reached, try again in "+wait.Round(time.Minute).String())
+	}
+
+	if q.PerMinute > 0 && q.minuteCalls >= q.PerMinute {
+		wait := q.minute.Add(time.Minute).Sub(now)
+		return errors.Wrap(ErrQuotaExhausted, "Limit of "+strconv.Itoa(q.PerMinute)+
+			" calls per minute reached, try again in "+wait.Round(time.Second).String())
+	}
+
+	q.minuteCalls++
+	q.dayCalls++
+
+	return nil
+}
+
+// Remaining returns how many calls are left this minute and today, -1 if there is no limit
+func (q *Quota) Remaining() QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reset(time.Now())
+
+	status := QuotaStatus{Minute: -1, Day: -1}
+
+	if q.PerMinute > 0 {
+		status.Minute = q.PerMinute - q.minuteCalls
+	}
+
+	if q.PerDay > 0 {
+		status.Day = q.PerDay - q.dayCalls
+	}
+
+	return status
+}
@@ -0,0 +1,35 @@
+package cravings
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestQuota(t *testing.T) {
+	q := NewQuota(2, 3)
+
+	for i := 0; i < 2; i++ { // two calls are allowed this minute
+		if err := q.Take(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	err := q.Take() // the third call is over the limit per minute
+	if errors.Cause(err) != ErrQuotaExhausted {
+		t.Error("expected quota to be exhausted, got", err)
+	}
+
+	status := q.Remaining()
+	if status.Minute != 0 || status.Day != 1 {
+		t.Error("wrong remaining quota", status)
+	}
+
+	unlimited := NewQuota(0, 0).Remaining()
+	if unlimited.Minute != -1 || unlimited.Day != -1 {
+		t.Error("quota without limits should have -1 remaining", unlimited)
+	}
+
+	fmt.Println("testing Quota")
+}
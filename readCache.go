@@ -0,0 +1,365 @@
+package cravings
+
+import (
+	"sort"
+	"sync"
+)
+
+// ReadCache keeps the recipes and ingredients read from the database in memory, so they are only read once.
+// It is updated with every document saved or deleted through the DB* functions, or changed by someone else in
+// firestore, see FirestoreDatabase.Watch. The ingredient cache and the recipe, search and suggest indexes are
+// views of it, updated with it and locked by its lock. Lookups only read lock it, see rlock
+type ReadCache struct {
+	mu                sync.RWMutex
+	recipes           map[string]Recipe       // recipes by ID, nil if they are not read
+	ingredients       map[string]Ingredient   // ingredients by ID, nil if they are not read
+	recipeChanges     int                     // changes to the recipes, so a read from before a change is not added
+	ingredientChanges int                     // changes to the ingredients, see recipeChanges
+	listMu            sync.Mutex              // locks the orders, which are sorted by readers
+	recipeOrders      map[string][]Recipe     // recipes sorted in every order they have been read in, until a change
+	ingredientOrders  map[string][]Ingredient // ingredients sorted in every order, see recipeOrders
+	recipeViews       []recipeView
+	ingredientViews   []ingredientView
+}
+
+// recipeView is kept up to date with the recipes in the cache. Its methods are called with the cache write locked
+type recipeView interface {
+	resetRecipes() // every recipe is taken out
+	addRecipe(rec Recipe)
+	removeRecipe(id string)
+}
+
+// ingredientView is kept up to date with the ingredients in the cache. Its methods are called with the cache write locked
+type ingredientView interface {
+	resetIngredients() // every ingredient is taken out
+	addIngredient(ing Ingredient)
+	removeIngredient(id string)
+}
+
+// readCache is the cache DBReadAllRecipes and DBReadAllIngredients read through
+var readCache = &ReadCache{
+	recipeViews:     []recipeView{recipeIndex, searchIndex, suggestIndex},
+	ingredientViews: []ingredientView{ingredientCache, suggestIndex},
+}
+
+func init() {
+	OnChange(readCache.changed)
+}
+
+// changed updates the cache and its views with a saved or deleted document. A collection that is not read
+// yet is read with the change when it is next used
+func (c *ReadCache) changed(event ChangeEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch event.Collection {
+	case "":
+		c.recipeChanges++
+		c.ingredientChanges++
+	case RecipeCollection:
+		c.recipeChanges++
+	case IngredientCollection:
+		c.ingredientChanges++
+	}
+
+	switch {
+	case event.Collection == "": // everything changed
+		c.resetRecipes()
+		c.resetIngredients()
+	case event.Collection == RecipeCollection && c.recipes != nil:
+		c.removeRecipe(event.ID) // a saved recipe may have been saved before
+		if !event.Deleted && event.Recipe != nil {
+			c.addRecipe(*event.Recipe)
+		}
+	case event.Collection == IngredientCollection && c.ingredients != nil:
+		c.removeIngredient(event.ID)
+		if !event.Deleted && event.Ingredient != nil {
+			c.addIngredient(*event.Ingredient)
+		}
+	}
+}
+
+// resetRecipes throws away the recipes, they are read again when they are next used
+func (c *ReadCache) resetRecipes() {
+	c.recipes, c.recipeOrders = nil, nil
+
+	for _, view := range c.recipeViews {
+		view.resetRecipes()
+	}
+}
+
+// addRecipe adds a copy of the recipe to the cache and its views, so the saved recipe can still be changed
+func (c *ReadCache) addRecipe(rec Recipe) {
+	rec = copyRecipe(rec)
+	c.recipes[rec.ID], c.recipeOrders = rec, nil
+
+	for _, view := range c.recipeViews {
+		view.addRecipe(rec)
+	}
+}
+
+// removeRecipe takes the recipe with the ID out of the cache and its views
+func (c *ReadCache) removeRecipe(id string) {
+	if _, ok := c.recipes[id]; !ok {
+		return
+	}
+
+	for _, view := range c.recipeViews {
+		view.removeRecipe(id)
+	}
+
+	delete(c.recipes, id)
+	c.recipeOrders = nil
+}
+
+// resetIngredients throws away the ingredients, they are read again when they are next used
+func (c *ReadCache) resetIngredients() {
+	c.ingredients, c.ingredientOrders = nil, nil
+
+	for _, view := range c.ingredientViews {
+		view.resetIngredients()
+	}
+}
+
+// addIngredient adds a copy of the ingredient to the cache and its views
+func (c *ReadCache) addIngredient(ing Ingredient) {
+	ing = copyIngredient(ing)
+	c.ingredients[ing.ID], c.ingredientOrders = ing, nil
+
+	for _, view := range c.ingredientViews {
+		view.addIngredient(ing)
+	}
+}
+
+// removeIngredient takes the ingredient with the ID out of the cache and its views
+func (c *ReadCache) removeIngredient(id string) {
+	if _, ok := c.ingredients[id]; !ok {
+		return
+	}
+
+	for _, view := range c.ingredientViews {
+		view.removeIngredient(id)
+	}
+
+	delete(c.ingredients, id)
+	c.ingredientOrders = nil
+}
+
+// rlock read locks the cache with the recipes and the ingredients in it if they are asked for. The ones that are
+// not in it are read from the database without the lock, and only added if they have not changed while reading.
+// If the error is nil the cache is read locked, and the caller has to call c.mu.RUnlock
+func (c *ReadCache) rlock(recipes bool, ingredients bool) error {
+	for {
+		c.mu.RLock()
+
+		loadRecipes, loadIngredients := recipes && c.recipes == nil, ingredients && c.ingredients == nil
+		if !loadRecipes && !loadIngredients {
+			return nil
+		}
+
+		recipeChanges, ingredientChanges := c.recipeChanges, c.ingredientChanges
+
+		c.mu.RUnlock()
+
+		if loadRecipes {
+			if err := c.loadRecipes(recipeChanges); err != nil {
+				return err
+			}
+		}
+
+		if loadIngredients {
+			if err := c.loadIngredients(ingredientChanges); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// loadRecipes reads the recipes from the database, and adds them to the cache if there has been no change to them
+// since the changes counted before reading. c.mu must not be locked by the caller
+func (c *ReadCache) loadRecipes(changes int) error {
+	recipes, err := Database.ReadAllRecipes()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.recipes != nil || c.recipeChanges != changes { // read by someone else, or changed while reading
+		return nil
+	}
+
+	c.resetRecipes() // the views are empty before the recipes are added
+	c.recipes = map[string]Recipe{}
+
+	for _, rec := range recipes {
+		c.addRecipe(rec)
+	}
+
+	return nil
+}
+
+// loadIngredients reads the ingredients from the database, see loadRecipes
+func (c *ReadCache) loadIngredients(changes int) error {
+	ingredients, err := Database.ReadAllIngredients()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ingredients != nil || c.ingredientChanges != changes {
+		return nil
+	}
+
+	c.resetIngredients()
+	c.ingredients = map[string]Ingredient{}
+
+	for _, ing := range ingredients {
+		c.addIngredient(ing)
+	}
+
+	return nil
+}
+
+// recipesInOrder returns the cached recipes sorted by less, and by ID when less does not tell them apart. The order
+// is the name of less, it is only sorted again after a change, and "" with less nil is by ID. c.mu has to be read
+// locked by the caller and the recipes loaded. The slice is shared, so it must not be changed
+func (c *ReadCache) recipesInOrder(order string, less func(a *Recipe, b *Recipe) bool) []Recipe {
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+
+	if recipes, ok := c.recipeOrders[order]; ok {
+		return recipes
+	}
+
+	if c.recipeOrders == nil {
+		c.recipeOrders = map[string][]Recipe{}
+	}
+
+	recipes, ok := c.recipeOrders[""]
+	if !ok {
+		recipes = make([]Recipe, 0, len(c.recipes))
+
+		for _, rec := range c.recipes {
+			recipes = append(recipes, rec)
+		}
+
+		sort.Slice(recipes, func(i, j int) bool {
+			return recipes[i].ID < recipes[j].ID
+		})
+
+		c.recipeOrders[""] = recipes
+	}
+
+	if order != "" {
+		recipes = append([]Recipe(nil), recipes...)
+
+		sort.SliceStable(recipes, func(i, j int) bool {
+			return less(&recipes[i], &recipes[j])
+		})
+
+		c.recipeOrders[order] = recipes
+	}
+
+	return recipes
+}
+
+// ingredientsInOrder returns the cached ingredients sorted by less, see recipesInOrder
+func (c *ReadCache) ingredientsInOrder(order string, less func(a *Ingredient, b *Ingredient) bool) []Ingredient {
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+
+	if ingredients, ok := c.ingredientOrders[order]; ok {
+		return ingredients
+	}
+
+	if c.ingredientOrders == nil {
+		c.ingredientOrders = map[string][]Ingredient{}
+	}
+
+	ingredients, ok := c.ingredientOrders[""]
+	if !ok {
+		ingredients = make([]Ingredient, 0, len(c.ingredients))
+
+		for _, ing := range c.ingredients {
+			ingredients = append(ingredients, ing)
+		}
+
+		sort.Slice(ingredients, func(i, j int) bool {
+			return ingredients[i].ID < ingredients[j].ID
+		})
+
+		c.ingredientOrders[""] = ingredients
+	}
+
+	if order != "" {
+		ingredients = append([]Ingredient(nil), ingredients...)
+
+		sort.SliceStable(ingredients, func(i, j int) bool {
+			return less(&ingredients[i], &ingredients[j])
+		})
+
+		c.ingredientOrders[order] = ingredients
+	}
+
+	return ingredients
+}
+
+// Recipes returns a copy of every recipe sorted by ID, which the caller can change
+func (c *ReadCache) Recipes() ([]Recipe, error) {
+	err := c.rlock(true, false)
+	if err != nil {
+		return []Recipe{}, err
+	}
+	defer c.mu.RUnlock()
+
+	recipes := make([]Recipe, 0, len(c.recipes))
+
+	for _, rec := range c.recipesInOrder("", nil) {
+		recipes = append(recipes, copyRecipe(rec))
+	}
+
+	return recipes, nil
+}
+
+// Ingredients returns a copy of every ingredient sorted by ID, which the caller can change
+func (c *ReadCache) Ingredients() ([]Ingredient, error) {
+	err := c.rlock(false, true)
+	if err != nil {
+		return []Ingredient{}, err
+	}
+	defer c.mu.RUnlock()
+
+	ingredients := make([]Ingredient, 0, len(c.ingredients))
+
+	for _, ing := range c.ingredientsInOrder("", nil) {
+		ingredients = append(ingredients, copyIngredient(ing))
+	}
+
+	return ingredients, nil
+}
+
+// RecipeCount returns how many recipes there are, without copying them
+func (c *ReadCache) RecipeCount() (int, error) {
+	err := c.rlock(true, false)
+	if err != nil {
+		return 0, err
+	}
+	defer c.mu.RUnlock()
+
+	return len(c.recipes), nil
+}
+
+// IngredientCount returns how many ingredients there are, without copying them
+func (c *ReadCache) IngredientCount() (int, error) {
+	err := c.rlock(false, true)
+	if err != nil {
+		return 0, err
+	}
+	defer c.mu.RUnlock()
+
+	return len(c.ingredients), nil
+}
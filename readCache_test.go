@@ -0,0 +1,157 @@
+package cravings
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// countingDatabase is a MemoryDatabase counting how many times the collections are read
+type countingDatabase struct {
+	*MemoryDatabase
+	recipeReads     int
+	ingredientReads int
+	recipePageReads int
+}
+
+func (db *countingDatabase) ReadAllRecipes() ([]Recipe, error) {
+	db.recipeReads++
+	return db.MemoryDatabase.ReadAllRecipes()
+}
+
+func (db *countingDatabase) ReadAllIngredients() ([]Ingredient, error) {
+	db.ingredientReads++
+	return db.MemoryDatabase.ReadAllIngredients()
+}
+
+func (db *countingDatabase) ReadRecipesPage(cursor string, limit int) ([]Recipe, string, error) {
+	db.recipePageReads++
+	return db.MemoryDatabase.ReadRecipesPage(cursor, limit)
+}
+
+func TestReadCache(t *testing.T) {
+	original := Database
+	defer UseDatabase(original) // restore database used by the other tests
+
+	db := &countingDatabase{MemoryDatabase: NewMemoryDatabase()}
+	UseDatabase(db)
+
+	rec := Recipe{RecipeName: "testcache pie", Ingredients: []Ingredient{{Name: "flour", Quantity: 1, Unit: "kg"}}}
+
+	err := DBSaveRecipe(&rec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipes, _ := DBReadAllRecipes(nil)
+	recipes[0].Ingredients[0].Quantity = 1000 // changing the result does not change the cache
+
+	recipes, _ = DBReadAllRecipes(nil)
+	if db.recipeReads != 1 {
+		t.Error("expected recipes to be read once, got", db.recipeReads)
+	}
+
+	if len(recipes) != 1 || recipes[0].Ingredients[0].Quantity != 1 {
+		t.Error("expected the cached recipe unchanged, got", recipes)
+	}
+
+	err = DBDelete(rec.ID, RecipeCollection, nil) // the cache is updated with a change, without reading again
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, _ := DBCountRecipes(nil)
+	if count != 0 || db.recipeReads != 1 {
+		t.Error("expected 0 recipes without reading again after delete, got", count, db.recipeReads)
+	}
+
+	// the indexes are views of the cache, so they do not read the recipes either
+	if results, _ := searchIndex.Search("testcache", 10, nil); len(results) != 0 || db.recipeReads != 1 {
+		t.Error("expected deleted recipe to be out of the search index, got", results, db.recipeReads)
+	}
+
+	_ = DBSaveIngredient(&Ingredient{Name: "testcache flour", Unit: "g", Quantity: 1}, nil)
+
+	_, _ = DBCountIngredients(nil)
+	count, _ = DBCountIngredients(nil) // from the cache
+
+	if count != 1 || db.ingredientReads != 1 || db.recipeReads != 1 {
+		t.Error("expected 1 ingredient read once, got", count, db.ingredientReads, db.recipeReads)
+	}
+
+	if _, err := ingredientCache.Find("testcache flours", nil); err != nil || db.ingredientReads != 1 {
+		t.Error("expected ingredient from the cache, got", err, db.ingredientReads)
+	}
+
+	UseDatabase(db) // everything is read again after the database changes
+	_, _ = DBCountRecipes(nil)
+
+	if db.recipeReads != 2 {
+		t.Error("expected recipes to be read again with a new database, got", db.recipeReads)
+	}
+
+	fmt.Println("testing ReadCache")
+}
+
+// changingDatabase is a MemoryDatabase calling during once while the recipes are read
+type changingDatabase struct {
+	*MemoryDatabase
+	during func()
+}
+
+func (db *changingDatabase) ReadAllRecipes() ([]Recipe, error) {
+	recipes, err := db.MemoryDatabase.ReadAllRecipes()
+
+	if during := db.during; during != nil {
+		db.during = nil
+		during()
+	}
+
+	return recipes, err
+}
+
+func TestReadCacheChangeWhileReading(t *testing.T) {
+	original := Database
+	defer UseDatabase(original) // restore database used by the other tests
+
+	db := &changingDatabase{MemoryDatabase: NewMemoryDatabase()}
+	UseDatabase(db)
+
+	rec := Recipe{RecipeName: "testlate pie", Ingredients: []Ingredient{{Name: "flour", Quantity: 1, Unit: "kg"}}}
+
+	// the recipe is saved after the recipes are read, so the read is thrown away and done again
+	db.during = func() { _ = DBSaveRecipe(&rec, nil) }
+
+	count, err := DBCountRecipes(nil)
+	if err != nil || count != 1 {
+		t.Error("expected the recipe saved while reading to be in the cache, got", count, err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ { // read and change the cache from many goroutines at once
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			_, _ = searchIndex.Search("testlate", 10, nil)
+			_, _ = recipeIndex.Uses(Ingredient{Name: "flour"}, nil)
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			r := Recipe{RecipeName: "testlate cake", Ingredients: []Ingredient{{Name: "flour", Quantity: 1, Unit: "kg"}}}
+			_ = DBSaveRecipe(&r, nil)
+		}()
+	}
+
+	wg.Wait()
+
+	if count, _ = DBCountRecipes(nil); count != 21 {
+		t.Error("expected 21 recipes, got", count)
+	}
+
+	fmt.Println("testing ReadCache change while reading")
+}
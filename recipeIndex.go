@@ -0,0 +1,125 @@
+package cravings
+
+import (
+	"net/http"
+	"sort"
+)
+
+// RecipeIndex is an in-memory index of which recipes in the read cache use every ingredient
+type RecipeIndex struct {
+	uses map[string]map[string]bool // IDs of the recipes using every ingredient, by normalised ingredient name
+}
+
+// recipeIndex is the index of the recipes using every ingredient
+var recipeIndex = &RecipeIndex{}
+
+// resetRecipes takes every recipe out of the index
+func (idx *RecipeIndex) resetRecipes() {
+	idx.uses = map[string]map[string]bool{}
+}
+
+// addRecipe indexes a recipe by the names of its ingredients
+func (idx *RecipeIndex) addRecipe(rec Recipe) {
+	for _, ing := range rec.Ingredients {
+		name := NormalizeName(ing.Name)
+		if idx.uses[name] == nil {
+			idx.uses[name] = map[string]bool{}
+		}
+
+		idx.uses[name][rec.ID] = true
+	}
+}
+
+// removeRecipe takes the recipe with the ID out of the index
+func (idx *RecipeIndex) removeRecipe(id string) {
+	for _, ing := range readCache.recipes[id].Ingredients {
+		name := NormalizeName(ing.Name)
+		delete(idx.uses[name], id)
+
+		if len(idx.uses[name]) == 0 {
+			delete(idx.uses, name)
+		}
+	}
+}
+
+// Uses returns the recipes using the ingredient by its name or one of its aliases, with how much of it
+// they use, sorted by recipe name
+func (idx *RecipeIndex) Uses(ing Ingredient, w http.ResponseWriter) ([]IngredientUse, error) {
+	err := readCache.rlock(true, false)
+	if err != nil {
+		return []IngredientUse{}, err
+	}
+	defer readCache.mu.RUnlock()
+
+	uses := []IngredientUse{}
+
+	for id := range idx.ids(ing) {
+		rec := readCache.recipes[id]
+
+		for _, i := range rec.Ingredients {
+			if ing.HasName(i.Name) {
+				uses = append(uses, IngredientUse{RecipeName: rec.RecipeName, Quantity: i.Quantity, Unit: i.Unit})
+			}
+		}
+	}
+
+	sort.Slice(uses, func(i, j int) bool {
+		return uses[i].RecipeName < uses[j].RecipeName
+	})
+
+	return uses, nil
+}
+
+// ids returns the IDs of the recipes using the ingredient by its name or one of its aliases
+func (idx *RecipeIndex) ids(ing Ingredient) map[string]bool {
+	ids := map[string]bool{}
+
+	for _, name := range append([]string{ing.Name}, ing.Aliases...) {
+		for id := range idx.uses[NormalizeName(name)] {
+			ids[id] = true
+		}
+	}
+
+	return ids
+}
+
+// Matches returns every recipe with how many of its ingredients are one of the ingredients by name, in no
+// particular order. Only the recipes using one of the ingredients are counted, the others have no matches.
+// The recipes share their ingredients with the read cache, so they must not be changed
+func (idx *RecipeIndex) Matches(ingredients []Ingredient, w http.ResponseWriter) ([]RecipeMatch, error) {
+	err := readCache.rlock(true, false)
+	if err != nil {
+		return []RecipeMatch{}, err
+	}
+	defer readCache.mu.RUnlock()
+
+	names := map[string]bool{}
+
+	for _, ing := range ingredients {
+		names[NormalizeName(ing.Name)] = true
+	}
+
+	counts := map[string]int{} // matches of the recipes using one of the ingredients, by ID
+
+	for name := range names {
+		for id := range idx.uses[name] {
+			counts[id] = 0
+		}
+	}
+
+	for id := range counts {
+		for _, i := range readCache.recipes[id].Ingredients {
+			if names[NormalizeName(i.Name)] {
+				counts[id]++
+			}
+		}
+	}
+
+	matches := make([]RecipeMatch, 0, len(readCache.recipes))
+
+	for id, rec := range readCache.recipes {
+		matches = append(matches, RecipeMatch{Recipe: rec, Matches: counts[id]})
+	}
+
+	return matches, nil
+}
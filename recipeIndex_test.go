@@ -0,0 +1,155 @@
+package cravings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecipeIndex(t *testing.T) {
+	ing := Ingredient{Name: "testflarn", Unit: "g", Quantity: 100, Aliases: []string{"testflurn"}}
+
+	err := DBSaveIngredient(&ing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBDelete(ing.ID, IngredientCollection, nil)
+
+	recipes := []Recipe{
+		{RecipeName: "testflarn bread", Ingredients: []Ingredient{{Name: "testflarns", Quantity: 200, Unit: "g"}}},
+		{RecipeName: "testflarn cake", Ingredients: []Ingredient{{Name: "testflurn", Quantity: 1, Unit: "kg"}}},
+	}
+
+	for i := range recipes {
+		err := DBSaveRecipe(&recipes[i], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	uses, err := recipeIndex.Uses(ing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// by plural and by alias, sorted by recipe name
+	if len(uses) != 2 || uses[0].RecipeName != "testflarn bread" || uses[0].Quantity != 200 ||
+		uses[1].RecipeName != "testflarn cake" || uses[1].Unit != "kg" {
+		t.Error("expected bread and cake, got", uses)
+	}
+
+	inside, _ := inRecipe(&ing, nil)
+	if !inside {
+		t.Error("expected testflarn to be in a recipe")
+	}
+
+	for i := range recipes {
+		err := DBDelete(recipes[i].ID, RecipeCollection, nil) // the index should be updated without the recipe
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inside, _ = inRecipe(&ing, nil)
+	if inside {
+		t.Error("expected testflarn not to be in a recipe after deleting them")
+	}
+
+	fmt.Println("testing RecipeIndex")
+}
+
+func TestRecipeIndexMatches(t *testing.T) {
+	recipes := []Recipe{
+		{RecipeName: "testquux pie", Ingredients: []Ingredient{{Name: "testquux", Quantity: 1, Unit: "pc"}}},
+		{RecipeName: "testquux jam", Ingredients: []Ingredient{{Name: "testfrob", Quantity: 1, Unit: "pc"},
+			{Name: "testquuxes", Quantity: 2, Unit: "pc"}}},
+		{RecipeName: "testfrob soup", Ingredients: []Ingredient{{Name: "testfrob", Quantity: 1, Unit: "pc"}}},
+	}
+
+	for i := range recipes {
+		err := DBSaveRecipe(&recipes[i], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer DBDelete(recipes[i].ID, RecipeCollection, nil)
+	}
+
+	// counts returns the matches of the test recipes by recipe name
+	counts := func(ingredients ...Ingredient) map[string]int {
+		matches, err := recipeIndex.Matches(ingredients, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		counts := map[string]int{}
+
+		for _, m := range matches {
+			if strings.HasPrefix(m.Recipe.RecipeName, "testquux") || strings.HasPrefix(m.Recipe.RecipeName, "testfrob") {
+				counts[m.Recipe.RecipeName] = m.Matches
+			}
+		}
+
+		return counts
+	}
+
+	// every recipe is returned, the ones not using the ingredient with no matches
+	c := counts(Ingredient{Name: "testquux"})
+	if len(c) != 3 || c["testquux pie"] != 1 || c["testquux jam"] != 1 || c["testfrob soup"] != 0 {
+		t.Error("expected one match in testquux jam and pie, got", c)
+	}
+
+	c = counts(Ingredient{Name: "testquux"}, Ingredient{Name: "testfrob"})
+	if c["testquux pie"] != 1 || c["testquux jam"] != 2 || c["testfrob soup"] != 1 {
+		t.Error("expected every ingredient of the three recipes to match, got", c)
+	}
+
+	fmt.Println("testing RecipeIndex.Matches")
+}
+
+func TestHandlerIngredientRecipes(t *testing.T) {
+	ing := Ingredient{Name: "testglorp", Unit: "g", Quantity: 100}
+
+	err := DBSaveIngredient(&ing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBDelete(ing.ID, IngredientCollection, nil)
+
+	rec := Recipe{RecipeName: "testglorp stew", Ingredients: []Ingredient{{Name: "testglorp", Quantity: 1500, Unit: "g"}}}
+
+	err = DBSaveRecipe(&rec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DBDelete(rec.ID, RecipeCollection, nil)
+
+	w := httptest.NewRecorder()
+
+	HandlerFood(w, httptest.NewRequest(http.MethodGet, "/cravings/food/ingredient/testglorp/recipes?units=metric", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatal(w.Code, w.Body.String())
+	}
+
+	uses := []IngredientUse{}
+
+	err = json.NewDecoder(w.Body).Decode(&uses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(uses) != 1 || uses[0].RecipeName != "testglorp stew" || uses[0].Quantity != 1.5 || uses[0].Unit != "kg" {
+		t.Error("expected 1.5 kg in testglorp stew, got", uses)
+	}
+
+	w = httptest.NewRecorder()
+
+	HandlerFood(w, httptest.NewRequest(http.MethodGet, "/cravings/food/ingredient/testnothing/recipes", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Error("expected not found for unknown ingredient, got", w.Code)
+	}
+}
@@ -0,0 +1,327 @@
+package cravings
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Fields of a recipe that are searched, and how much a match in each of them counts
+const (
+	fieldName = iota
+	fieldIngredients
+	fieldDescription
+	fieldCount
+)
+
+var fieldWeights = [fieldCount]float64{fieldName: 3, fieldIngredients: 2, fieldDescription: 1}
+
+// SearchIndex is an in-memory full-text index of the names, ingredient names and descriptions of the recipes in
+// the read cache
+type SearchIndex struct {
+	docs  map[string]*searchDoc      // recipes by ID
+	terms map[string]map[string]bool // IDs of the recipes every term is in
+}
+
+// searchDoc is an indexed recipe. Every field is a list of texts, i.e. one per ingredient or step,
+// so a phrase is not matched across two of them
+type searchDoc struct {
+	fields [fieldCount][][]string
+}
+
+// searchItem is a word or phrase in a query
+type searchItem struct {
+	terms   []string
+	exclude bool // the recipe must not contain it
+}
+
+// searchIndex is the index used by /cravings/food/search
+var searchIndex = &SearchIndex{}
+
+// resetRecipes takes every recipe out of the index
+func (idx *SearchIndex) resetRecipes() {
+	idx.docs = map[string]*searchDoc{}
+	idx.terms = map[string]map[string]bool{}
+}
+
+// addRecipe indexes a recipe by its ID
+func (idx *SearchIndex) addRecipe(rec Recipe) {
+	doc := &searchDoc{}
+	doc.fields[fieldName] = [][]string{searchTerms(rec.RecipeName)}
+
+	for _, ing := range rec.Ingredients {
+		doc.fields[fieldIngredients] = append(doc.fields[fieldIngredients], searchTerms(ing.Name))
+	}
+
+	for _, step := range rec.Description {
+		doc.fields[fieldDescription] = append(doc.fields[fieldDescription], searchTerms(step))
+	}
+
+	idx.docs[rec.ID] = doc
+
+	for _, field := range doc.fields {
+		for _, text := range field {
+			for _, term := range text {
+				if idx.terms[term] == nil {
+					idx.terms[term] = map[string]bool{}
+				}
+
+				idx.terms[term][rec.ID] = true
+			}
+		}
+	}
+}
+
+// removeRecipe takes the recipe with the ID out of the index
+func (idx *SearchIndex) removeRecipe(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, field := range doc.fields {
+		for _, text := range field {
+			for _, term := range text {
+				delete(idx.terms[term], id)
+
+				if len(idx.terms[term]) == 0 {
+					delete(idx.terms, term)
+				}
+			}
+		}
+	}
+
+	delete(idx.docs, id)
+}
+
+// searchTerms splits a text into the terms it is indexed by, lowercase words with the same singular and plural
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i := range words {
+		words[i] = singular(words[i])
+	}
+
+	return words
+}
+
+// ParseSearchQuery reads a search query into groups of words and phrases, a recipe matches if it matches every
+// item in one of the groups. Words are separated by spaces and all have to match, "quoted words" are a phrase that
+// has to be in that order, OR separates groups, and NOT or - before a word or phrase excludes recipes with it.
+// AND between words is allowed, but does nothing
+func ParseSearchQuery(q string) ([][]searchItem, error) {
+	groups := [][]searchItem{}
+	group := []searchItem{}
+	exclude := false
+
+	// endGroup adds the group to groups, a group has to have something the recipes should contain
+	endGroup := func() error {
+		if exclude {
+			return errors.New("Nothing after NOT in search query")
+		}
+
+		included := false
+
+		for _, item := range group {
+			included = included || !item.exclude
+		}
+
+		if !included {
+			return errors.New("Every part of a search query between OR has to have a word that is not excluded")
+		}
+
+		groups = append(groups, group)
+		group = []searchItem{}
+
+		return nil
+	}
+
+	text := strings.TrimSpace(q)
+
+	for text != "" {
+		var token string
+
+		phrase := strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "-\"")
+		if phrase {
+			start := strings.Index(text, "\"") + 1
+
+			end := strings.Index(text[start:], "\"")
+			if end < 0 {
+				return groups, errors.New("Missing \" after phrase in search query")
+			}
+
+			token, text = text[:start+end+1], text[start+end+1:]
+		} else if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+			token, text = text[:i], text[i:]
+		} else {
+			token, text = text, ""
+		}
+
+		text = strings.TrimSpace(text)
+
+		switch {
+		case !phrase && token == "OR":
+			if err := endGroup(); err != nil {
+				return groups, err
+			}
+		case !phrase && token == "AND":
+		case !phrase && token == "NOT":
+			exclude = true
+		default:
+			if strings.HasPrefix(token, "-") {
+				exclude = true
+				token = token[1:]
+			}
+
+			terms := searchTerms(token)
+			if len(terms) > 0 { // a word without letters or digits does not match anything
+				group = append(group, searchItem{terms: terms, exclude: exclude})
+			}
+
+			exclude = false
+		}
+	}
+
+	if err := endGroup(); err != nil {
+		return groups, err
+	}
+
+	return groups, nil
+}
+
+// Search returns at most limit recipes matching the query, see ParseSearchQuery, best match first.
+// Matches in recipe names count more than in ingredient names, and those more than in the description.
+// Rare words count more than common ones, and a match in a short text more than in a long one
+func (idx *SearchIndex) Search(q string, limit int, w http.ResponseWriter) ([]SearchResult, error) {
+	groups, err := ParseSearchQuery(q)
+	if err != nil {
+		return []SearchResult{}, err
+	}
+
+	err = readCache.rlock(true, false)
+	if err != nil {
+		return []SearchResult{}, err
+	}
+	defer readCache.mu.RUnlock()
+
+	scores := map[string]float64{}
+
+	for _, group := range groups {
+		for id, score := range idx.matchGroup(group) {
+			scores[id] += score
+		}
+	}
+
+	results := []SearchResult{}
+
+	for id, score := range scores {
+		results = append(results, SearchResult{Recipe: copyRecipe(readCache.recipes[id]), Score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		return results[i].Recipe.RecipeName < results[j].Recipe.RecipeName
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// matchGroup returns the score of every recipe that matches all items in the group
+func (idx *SearchIndex) matchGroup(group []searchItem) map[string]float64 {
+	var candidates map[string]bool // recipes with every included term, checked for phrases below
+
+	for _, item := range group {
+		if item.exclude {
+			continue
+		}
+
+		for _, term := range item.terms {
+			next := map[string]bool{}
+
+			for id := range idx.terms[term] {
+				if candidates == nil || candidates[id] {
+					next[id] = true
+				}
+			}
+
+			candidates = next
+		}
+	}
+
+	scores := map[string]float64{}
+
+candidates:
+	for id := range candidates {
+		doc := idx.docs[id]
+		score := 0.0
+
+		for _, item := range group {
+			itemScore := idx.score(doc, item.terms)
+
+			if item.exclude != (itemScore == 0) {
+				continue candidates
+			}
+
+			score += itemScore
+		}
+
+		scores[id] = score
+	}
+
+	return scores
+}
+
+// score returns how well the phrase matches the recipe, 0 if it is not in it
+func (idx *SearchIndex) score(doc *searchDoc, phrase []string) float64 {
+	idf := 0.0 // rare words count more
+	for _, term := range phrase {
+		idf += math.Log(1 + float64(len(idx.docs))/float64(1+len(idx.terms[term])))
+	}
+
+	score := 0.0
+
+	for field, texts := range doc.fields {
+		for _, text := range texts {
+			if n := countPhrase(text, phrase); n > 0 {
+				score += fieldWeights[field] * idf * float64(n) / math.Sqrt(float64(len(text)))
+			}
+		}
+	}
+
+	return score
+}
+
+// countPhrase returns how many times the phrase is in the text
+func countPhrase(text []string, phrase []string) int {
+	count := 0
+
+	for i := 0; i+len(phrase) <= len(text); i++ {
+		match := true
+
+		for j := range phrase {
+			if text[i+j] != phrase[j] {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,122 @@
+package cravings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	groups, err := ParseSearchQuery(`tomatoes "Olive oil" AND -garlic OR NOT basil soup`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 2 || len(groups[0]) != 3 || len(groups[1]) != 2 {
+		t.Fatal("expected groups of 3 and 2 items, got", groups)
+	}
+
+	if groups[0][0].terms[0] != "tomato" || groups[0][0].exclude {
+		t.Error("expected tomato, got", groups[0][0])
+	}
+
+	if len(groups[0][1].terms) != 2 || groups[0][1].terms[1] != "oil" {
+		t.Error("expected phrase olive oil, got", groups[0][1])
+	}
+
+	if !groups[0][2].exclude || !groups[1][0].exclude || groups[1][1].exclude {
+		t.Error("expected garlic and basil excluded, got", groups)
+	}
+
+	for _, q := range []string{`-garlic`, `"olive oil`, `soup OR`, `soup NOT`} {
+		if _, err := ParseSearchQuery(q); err == nil {
+			t.Error("expected error for", q)
+		}
+	}
+
+	fmt.Println("testing ParseSearchQuery")
+}
+
+func TestSearchIndex(t *testing.T) {
+	recipes := []Recipe{
+		{RecipeName: "zorblax soup", Ingredients: []Ingredient{{Name: "quibble beans"}},
+			Description: []string{"Boil the beans", "Add zorblax and simmer"}},
+		{RecipeName: "quibble salad", Ingredients: []Ingredient{{Name: "zorblax"}, {Name: "beans"}},
+			Description: []string{"Mix quibble and beans"}},
+	}
+
+	for i := range recipes {
+		err := DBSaveRecipe(&recipes[i], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer DBDelete(recipes[1].ID, RecipeCollection, nil)
+
+	results, err := searchIndex.Search("zorblax", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a match in the name counts more than one in ingredients
+	if len(results) != 2 || results[0].Recipe.RecipeName != "zorblax soup" {
+		t.Error("expected zorblax soup first, got", results)
+	}
+
+	results, _ = searchIndex.Search(`"quibble beans"`, 10, nil) // only in the ingredient of the soup
+	if len(results) != 1 || results[0].Recipe.RecipeName != "zorblax soup" {
+		t.Error("expected only zorblax soup for phrase, got", results)
+	}
+
+	results, _ = searchIndex.Search("zorblax -soup", 10, nil)
+	if len(results) != 1 || results[0].Recipe.RecipeName != "quibble salad" {
+		t.Error("expected only quibble salad, got", results)
+	}
+
+	results, _ = searchIndex.Search("simmer OR mix", 10, nil)
+	if len(results) != 2 {
+		t.Error("expected both recipes, got", results)
+	}
+
+	err = DBDelete(recipes[0].ID, RecipeCollection, nil) // the index should be updated without the recipe
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, _ = searchIndex.Search("zorblax", 10, nil)
+	if len(results) != 1 || results[0].Recipe.RecipeName != "quibble salad" {
+		t.Error("expected deleted recipe to be gone, got", results)
+	}
+
+	fmt.Println("testing SearchIndex")
+}
+
+func TestHandlerSearch(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	HandlerFood(w, httptest.NewRequest(http.MethodGet, "/cravings/food/search?q=pancakes", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatal(w.Code, w.Body.String())
+	}
+
+	results := []SearchResult{}
+
+	err := json.NewDecoder(w.Body).Decode(&results)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, q := range []string{"", "?q=-pancakes"} {
+		w = httptest.NewRecorder()
+
+		HandlerFood(w, httptest.NewRequest(http.MethodGet, "/cravings/food/search"+q, nil))
+
+		if w.Code != http.StatusBadRequest {
+			t.Error("expected bad request for", q, "got", w.Code)
+		}
+	}
+}
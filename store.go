@@ -0,0 +1,254 @@
+package cravings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Store is the storage backend for recipes, ingredients, webhooks and tokens.
+// All the DB* functions used by the handlers go through the Store in Database
+type Store interface {
+	Init() error  // Connects to or opens the backend
+	Close() error // Releases the backend
+
+	SaveRecipe(r *Recipe) error         // Saves recipe and sets its ID
+	SaveIngredient(i *Ingredient) error // Saves ingredient and sets its ID
+	SaveWebhook(wh *Webhook) error      // Saves webhook and sets its ID
+	Delete(id string, collection string) error
+
+	ReadRecipeByName(name string) (Recipe, error)
+	ReadIngredientByName(name string) (Ingredient, error)
+	ReadAllRecipes() ([]Recipe, error)
+	ReadAllIngredients() ([]Ingredient, error)
+	ReadAllWebhooks() ([]Webhook, error)
+
+	// Read at most limit documents ordered by ID, after the one with the cursor as ID. Returns the cursor of
+	// the next page, which is empty after the last page
+	ReadRecipesPage(cursor string, limit int) ([]Recipe, string, error)
+	ReadIngredientsPage(cursor string, limit int) ([]Ingredient, string, error)
+
+	CheckToken(token string) (bool, error) // Returns true if token is in the tokens collection
+}
+
+// TokenSaver is implemented by the stores which the program can add approved tokens to.
+// Tokens in firestore are still only added manually
+type TokenSaver interface {
+	SaveToken(t *Token) error
+}
+
+// ChangeWatcher is implemented by the stores that can be changed by others than this program, i.e. another instance
+// using the same firestore. Watch calls notify for every change they make, so the in-memory indexes stay up to date
+type ChangeWatcher interface {
+	Watch(notify func(ChangeEvent)) error
+}
+
+// Database is the store used by the DB* functions, firestore by default
+var Database Store = &fireBaseDB
+
+// ChangeEvent is a change made to the database through the DB* functions
+type ChangeEvent struct {
+	Collection string      // RecipeCollection, IngredientCollection or WebhooksCollection, empty if everything changed
+	ID         string      // ID of the document that changed
+	Deleted    bool        // The document was deleted, else it was saved
+	Recipe     *Recipe     // The saved recipe
+	Ingredient *Ingredient // The saved ingredient
+}
+
+// changeListeners are called after every change to the database
+var changeListeners struct {
+	mu        sync.RWMutex
+	listeners []func(ChangeEvent)
+}
+
+// OnChange registers a function called after every change to the database, so in-memory indexes can be kept up to date
+func OnChange(listener func(ChangeEvent)) {
+	changeListeners.mu.Lock()
+	defer changeListeners.mu.Unlock()
+
+	changeListeners.listeners = append(changeListeners.listeners, listener)
+}
+
+// notifyChange calls the change listeners with the event
+func notifyChange(event ChangeEvent) {
+	changeListeners.mu.RLock()
+	defer changeListeners.mu.RUnlock()
+
+	for _, listener := range changeListeners.listeners {
+		listener(event)
+	}
+}
+
+// SelectDatabase sets Database to the backend with the given name, either "firestore", "memory" or "bolt".
+// An empty name selects firestore
+func SelectDatabase(name string) error {
+	switch strings.ToLower(name) {
+	case "", DatabaseFirestore:
+		UseDatabase(&fireBaseDB)
+	case DatabaseMemory:
+		UseDatabase(NewMemoryDatabase())
+	case DatabaseBolt:
+		UseDatabase(&BoltDatabase{Path: BoltFile})
+	default:
+		return errors.New("Unknown database " + name)
+	}
+
+	return nil
+}
+
+// UseDatabase sets Database to the store, and lets the in-memory indexes know everything has changed
+func UseDatabase(store Store) {
+	Database = store
+
+	notifyChange(ChangeEvent{}) // everything is different in the new database
+}
+
+// DBInit initialises the database, and watches it for changes made by others if it can
+func DBInit() error {
+	err := Database.Init()
+	if err != nil {
+		return err
+	}
+
+	if watcher, ok := Database.(ChangeWatcher); ok {
+		return watcher.Watch(notifyChange)
+	}
+
+	return nil
+}
+
+// DBClose closes the database connection
+func DBClose() {
+	err := Database.Close()
+	if err != nil {
+		fmt.Println("Failed to close database: " + err.Error())
+	} else {
+		fmt.Println("Successfully closed database")
+	}
+}
+
+// DBSaveRecipe saves recipe to database
+func DBSaveRecipe(r *Recipe, w http.ResponseWriter) error {
+	err := Database.SaveRecipe(r)
+	if err == nil {
+		notifyChange(ChangeEvent{Collection: RecipeCollection, ID: r.ID, Recipe: r})
+	}
+
+	return err
+}
+
+// DBSaveIngredient saves ingredient to database
+func DBSaveIngredient(i *Ingredient, w http.ResponseWriter) error {
+	err := Database.SaveIngredient(i)
+	if err == nil {
+		notifyChange(ChangeEvent{Collection: IngredientCollection, ID: i.ID, Ingredient: i})
+	}
+
+	return err
+}
+
+// DBSaveWebhook saves a new webhook to the database
+func DBSaveWebhook(i *Webhook, w http.ResponseWriter) error {
+	err := Database.SaveWebhook(i)
+	if err == nil {
+		notifyChange(ChangeEvent{Collection: WebhooksCollection, ID: i.ID})
+	}
+
+	return err
+}
+
+// DBDelete deletes an entry from given collection in database by its id, either ingredient, recipe or webhook
+func DBDelete(id string, collection string, w http.ResponseWriter) error {
+	err := Database.Delete(id, collection)
+	if err == nil {
+		notifyChange(ChangeEvent{Collection: collection, ID: id, Deleted: true})
+	}
+
+	if err == nil && collection == WebhooksCollection {
+		RemoveWebhookBreaker(id) // the breaker of a deleted webhook is not used again
+	}
+
+	return err
+}
+
+// DBReadRecipeByName reads a single recipe by Name
+func DBReadRecipeByName(name string, w http.ResponseWriter) (Recipe, error) {
+	return Database.ReadRecipeByName(name)
+}
+
+// DBReadIngredientByName reads a single ingredient by name
+func DBReadIngredientByName(name string, w http.ResponseWriter) (Ingredient, error) {
+	return Database.ReadIngredientByName(name)
+}
+
+// DBReadAllRecipes reads all recipes from database, through the read cache
+func DBReadAllRecipes(w http.ResponseWriter) ([]Recipe, error) {
+	return readCache.Recipes()
+}
+
+// DBReadAllIngredients reads all ingredients from database, through the read cache
+func DBReadAllIngredients(w http.ResponseWriter) ([]Ingredient, error) {
+	return readCache.Ingredients()
+}
+
+// DBReadRecipesPage reads a page of at most limit recipes from the database, after the cursor of the previous page.
+// An empty cursor reads the first page, and the returned cursor is empty after the last page
+func DBReadRecipesPage(cursor string, limit int, w http.ResponseWriter) ([]Recipe, string, error) {
+	if limit <= 0 {
+		return []Recipe{}, "", errors.New("A page has to have at least 1 recipe")
+	}
+
+	return Database.ReadRecipesPage(cursor, limit)
+}
+
+// DBReadIngredientsPage reads a page of at most limit ingredients from the database, see DBReadRecipesPage
+func DBReadIngredientsPage(cursor string, limit int, w http.ResponseWriter) ([]Ingredient, string, error) {
+	if limit <= 0 {
+		return []Ingredient{}, "", errors.New("A page has to have at least 1 ingredient")
+	}
+
+	return Database.ReadIngredientsPage(cursor, limit)
+}
+
+// DBCountRecipes returns how many recipes there are in the database, from the read cache
+func DBCountRecipes(w http.ResponseWriter) (int, error) {
+	return readCache.RecipeCount()
+}
+
+// DBCountIngredients returns how many ingredients there are in the database, from the read cache
+func DBCountIngredients(w http.ResponseWriter) (int, error) {
+	return readCache.IngredientCount()
+}
+
+// DBReadAllWebhooks returns all registered webhooks in the database
+func DBReadAllWebhooks(w http.ResponseWriter) ([]Webhook, error) {
+	return Database.ReadAllWebhooks()
+}
+
+// DBCheckAuthorization func is used in the register functions
+// to see if the user has authorization to upload data to the DB
+// The authorization token is one which we the creators of the program has created and saved manually
+// For security purposes we have chosen not to include code which saves the token, and the token itself can be given
+// to the reviewers of this project by mail which can be found in the readme
+func DBCheckAuthorization(w http.ResponseWriter, r *http.Request) (bool, []byte, error) {
+	tempToken := Token{}
+	resp, err := ioutil.ReadAll(r.Body) //  Read the body of the json posted with the authentication token
+
+	if err != nil {
+		return false, resp, errors.Wrap(err, "Couldn't read request: "+err.Error())
+	}
+
+	err = json.Unmarshal(resp, &tempToken)
+	if err != nil {
+		return false, resp, errors.Wrap(err, "Unable to unmarshal request body: "+err.Error())
+	}
+
+	authorised, err := Database.CheckToken(tempToken.AuthToken) // Look for the token in the tokens collection
+
+	return authorised, resp, err
+}
@@ -0,0 +1,208 @@
+package cravings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// testNutrients is the nutrient table used when testing offline, nutrients for 1 g or 1 l
+var testNutrients = []Ingredient{
+	{Name: "turmeric", Unit: "g", Quantity: 1, Calories: 3.12, Weight: 1, Nutrients: NewTotalNutrients(3.12, 0.03, 0.65, 0.03, 0.1)},
+	{Name: "milk", Unit: "l", Quantity: 1, Calories: 640, Weight: 1030, Nutrients: NewTotalNutrients(640, 33, 49, 52, 33)},
+	{Name: "olive oil", Unit: "l", Quantity: 1, Calories: 8100, Weight: 916, Nutrients: NewTotalNutrients(8100, 916, 0, 0, 0)},
+	{Name: "flour", Unit: "g", Quantity: 1, Calories: 3.64, Weight: 1, Nutrients: NewTotalNutrients(3.64, 0.01, 0.76, 0, 0.1)},
+	{Name: "salt", Unit: "g", Quantity: 1, Calories: 0, Weight: 1, Nutrients: NewTotalNutrients(0, 0, 0, 0, 0)},
+	{Name: "salt", Unit: "tablespoon", Quantity: 1, Calories: 0, Weight: 18, Nutrients: NewTotalNutrients(0, 0, 0, 0, 0)},
+}
+
+func TestMain(m *testing.M) {
+	// Without the firestore credentials file the tests run against the in-memory database
+	if _, err := os.Stat(FirestoreCredentials); err != nil {
+		fmt.Println("No credentials for firestore, testing with in-memory database")
+
+		db := NewMemoryDatabase()
+		if token := TempToken(); token != "" {
+			_ = db.SaveToken(&Token{AuthToken: token})
+		}
+
+		Database = db
+
+		// Nutrients for the ingredients used by the handler tests, so they do not need Edamam either
+		Nutrition = NewLocalNutritionProviderFrom(testNutrients)
+
+		for i := range testNutrients {
+			// turmeric is registered by TestHandlerFood, and the database only has ingredients in g or l
+			if testNutrients[i].Name != "turmeric" && testNutrients[i].Unit != "tablespoon" {
+				ing := testNutrients[i]
+				_ = db.SaveIngredient(&ing)
+			}
+		}
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestSelectDatabase(t *testing.T) {
+	original := Database
+	defer UseDatabase(original) // restore database used by the other tests
+
+	err := SelectDatabase(DatabaseMemory)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if _, ok := Database.(*MemoryDatabase); !ok {
+		t.Error("memory database was not selected")
+	}
+
+	err = SelectDatabase("")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if Database != &fireBaseDB {
+		t.Error("firestore should be the default database")
+	}
+
+	err = SelectDatabase("something") // unknown database, error is supposed to be sent
+	if err == nil {
+		t.Error("unknown database was selected")
+	}
+
+	fmt.Println("testing SelectDatabase")
+}
+
+func TestStorePages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cravings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bolt := &BoltDatabase{Path: filepath.Join(dir, "test.db")}
+
+	err = bolt.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	for name, db := range map[string]Store{DatabaseMemory: NewMemoryDatabase(), DatabaseBolt: bolt} {
+		saved := map[string]bool{}
+
+		for i := 0; i < 5; i++ {
+			rec := Recipe{RecipeName: "page recipe " + strconv.Itoa(i)}
+			_ = db.SaveRecipe(&rec)
+			ing := Ingredient{Name: "page ingredient " + strconv.Itoa(i)}
+			_ = db.SaveIngredient(&ing)
+			saved[rec.ID], saved[ing.ID] = true, true
+		}
+
+		read := map[string]bool{}
+		recipePages, ingredientPages := 0, 0
+
+		for cursor := ""; recipePages == 0 || cursor != ""; recipePages++ { // every recipe once, in pages of 2
+			var recipes []Recipe
+
+			recipes, cursor, err = db.ReadRecipesPage(cursor, 2)
+			if err != nil || len(recipes) > 2 {
+				t.Fatal(name, "could not read page", recipes, err)
+			}
+
+			for _, r := range recipes {
+				read[r.ID] = true
+			}
+		}
+
+		for cursor := ""; ingredientPages == 0 || cursor != ""; ingredientPages++ { // in pages of 3
+			var ingredients []Ingredient
+
+			ingredients, cursor, err = db.ReadIngredientsPage(cursor, 3)
+			if err != nil || len(ingredients) > 3 {
+				t.Fatal(name, "could not read page", ingredients, err)
+			}
+
+			for _, i := range ingredients {
+				read[i.ID] = true
+			}
+		}
+
+		if recipePages != 3 || ingredientPages != 2 || len(read) != len(saved) {
+			t.Error(name, "expected 10 documents in 3 + 2 pages, got", len(read), "in", recipePages, ingredientPages)
+		}
+
+		recipe, err := db.ReadRecipeByName("page recipe 3") // through the name index
+		if err != nil || recipe.RecipeName != "page recipe 3" {
+			t.Error(name, "could not read recipe by name", recipe, err)
+		}
+
+		ingredient, _ := db.ReadIngredientByName("page ingredient 3")
+
+		err = db.Delete(ingredient.ID, IngredientCollection)
+		if err != nil {
+			t.Error(name, err)
+		}
+
+		if _, err := db.ReadIngredientByName("page ingredient 3"); err == nil {
+			t.Error(name, "deleted ingredient is still in the name index")
+		}
+
+		if _, err := db.ReadIngredientByName("page ingredient 4"); err != nil {
+			t.Error(name, "could not read ingredient after deleting another", err)
+		}
+	}
+
+	fmt.Println("testing Store pages")
+}
+
+func TestStoreDuplicateNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cravings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bolt := &BoltDatabase{Path: filepath.Join(dir, "test.db")}
+
+	err = bolt.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	for name, db := range map[string]Store{DatabaseMemory: NewMemoryDatabase(), DatabaseBolt: bolt} {
+		first, second := Recipe{RecipeName: "twin pie"}, Recipe{RecipeName: "twin pie"}
+		_ = db.SaveRecipe(&first)
+		_ = db.SaveRecipe(&second)
+
+		if first.ID > second.ID { // the recipe with the lowest id is read by name
+			first, second = second, first
+		}
+
+		recipe, err := db.ReadRecipeByName("twin pie")
+		if err != nil || recipe.ID != first.ID {
+			t.Error(name, "expected recipe with the lowest id, got", recipe, err)
+		}
+
+		_ = db.Delete(first.ID, RecipeCollection) // the other recipe with the name is still found
+
+		recipe, err = db.ReadRecipeByName("twin pie")
+		if err != nil || recipe.ID != second.ID {
+			t.Error(name, "expected the remaining recipe after delete, got", recipe, err)
+		}
+
+		_ = db.Delete(second.ID, RecipeCollection)
+
+		if _, err := db.ReadRecipeByName("twin pie"); err == nil {
+			t.Error(name, "expected no recipe after deleting both")
+		} else if _, ok := notFound(err); !ok {
+			t.Error(name, "expected *NotFoundError after deleting both, got", err)
+		}
+	}
+
+	fmt.Println("testing Store duplicate names")
+}
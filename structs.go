@@ -2,9 +2,11 @@ package cravings
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/firestore"
+	bolt "go.etcd.io/bbolt"
 )
 
 // Recipe Struct for a recipe which contains ingredients used in firebase.go and register.go -
@@ -26,15 +28,58 @@ type RecipePrint struct {
 	} `json:"ingredients"`
 }
 
+// Suggestion is a name suggested by the autocomplete endpoint
+type Suggestion struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`            // "ingredient" or "recipe"
+	Alias string `json:"alias,omitempty"` // The alias of the ingredient that matched, if it was not the name
+}
+
+// SearchResult is a recipe found by the search endpoint, with how well it matched
+type SearchResult struct {
+	Recipe Recipe  `json:"recipe"`
+	Score  float64 `json:"score"`
+}
+
+// IngredientUse is a recipe using an ingredient, and how much of it the recipe uses
+type IngredientUse struct {
+	RecipeName string  `json:"recipeName"`
+	Quantity   float64 `json:"quantity"`
+	Unit       string  `json:"unit"`
+}
+
+// RecipeMatch is a recipe, and how many of its ingredients are in a meal by name
+type RecipeMatch struct {
+	Recipe  Recipe
+	Matches int
+}
+
+// MealPrint is the response of /cravings/meal with autocorrect, the recipes and the ingredients that were read as
+// another ingredient
+type MealPrint struct {
+	Recipes     []RecipePrint    `json:"recipes"`
+	Corrections []ItemCorrection `json:"corrections"`
+}
+
+// ListPage is a page of recipes or ingredients, with the cursor and URL of the next page
+type ListPage struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"nextCursor,omitempty"` // Empty on the last page
+	Next       string      `json:"next,omitempty"`       // URL of the next page, also in the Link header
+}
+
 // Ingredient Struct for an ingredient used in firebase.go and register.go
 type Ingredient struct {
-	ID        string         `json:"id"`
-	Quantity  float64        `json:"quantity"`
-	Unit      string         `json:"unit"`
-	Name      string         `json:"name"`
-	Calories  float64        `json:"calories"`
-	Weight    float64        `json:"totalWeight"`
-	Nutrients TotalNutrients `json:"totalNutrients"`
+	ID          string         `json:"id"`
+	Quantity    float64        `json:"quantity"`
+	Unit        string         `json:"unit"`
+	Name        string         `json:"name"`
+	Calories    float64        `json:"calories"`
+	Weight      float64        `json:"totalWeight"`
+	Nutrients   TotalNutrients `json:"totalNutrients"`
+	Density     float64        `json:"density,omitempty"`     // g/ml, used to convert between weight and volume
+	PieceWeight float64        `json:"pieceWeight,omitempty"` // g, used to convert between pieces and weight
+	Aliases     []string       `json:"aliases,omitempty"`     // Other names for the ingredient, i.e. "courgette"
 }
 
 // Webhook Struct for an webhook used in firebase.go and webhooks.go
@@ -63,8 +108,27 @@ type TotalNutrients struct {
 
 // FirestoreDatabase implements our Database access through Firestore
 type FirestoreDatabase struct {
-	Ctx    context.Context
-	Client *firestore.Client
+	Ctx       context.Context
+	Client    *firestore.Client
+	stopWatch context.CancelFunc // stops the snapshot listeners started by Watch
+}
+
+// MemoryDatabase implements our Database access in memory, used for tests and local development
+type MemoryDatabase struct {
+	mu              sync.RWMutex
+	recipes         []Recipe
+	ingredients     []Ingredient
+	webhooks        []Webhook
+	tokens          []Token
+	recipeNames     nameIndex      // recipe ids by name
+	ingredientNames nameIndex      // ingredient ids by name
+	positions       map[string]int // positions of the recipes and ingredients in their slices by id
+}
+
+// BoltDatabase implements our Database access through an embedded bolt database file
+type BoltDatabase struct {
+	Path string
+	db   *bolt.DB
 }
 
 // Token for access to modification in our database. Needed for POST and DELETE
@@ -75,12 +139,27 @@ type Token struct {
 
 // Status struct for status endpoint
 type Status struct {
-	Edamam           int     `json:"edamam"`
-	Database         int     `json:"database"`
-	TotalRecipe      int     `json:"total recipes"`
-	TotalIngredients int     `json:"total ingredients"`
-	Uptime           float64 `json:"uptime"`
-	Version          string  `json:"version"`
+	Edamam           int             `json:"edamam"`
+	Database         int             `json:"database"`
+	TotalRecipe      int             `json:"total recipes"`
+	TotalIngredients int             `json:"total ingredients"`
+	EdamamQuota      QuotaStatus     `json:"edamam quota"`
+	Outbound         []BreakerStatus `json:"outbound"`
+	Uptime           float64         `json:"uptime"`
+	Version          string          `json:"version"`
+}
+
+// BreakerStatus is the state of the circuit breaker for calls to one destination
+type BreakerStatus struct {
+	Destination string `json:"destination"`
+	State       string `json:"state"`
+	Failures    int    `json:"failures"`
+}
+
+// QuotaStatus is the number of calls left to an external API, -1 if there is no limit
+type QuotaStatus struct {
+	Minute int `json:"minute"`
+	Day    int `json:"day"`
 }
 
 // TestIngredient struct for testing handlerFood
@@ -0,0 +1,166 @@
+package cravings
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// SuggestIndex is an in-memory index of the ingredient names, aliases and recipe names in the read cache,
+// for autocomplete
+type SuggestIndex struct {
+	ingredients map[string][]suggestEntry // names and aliases of every ingredient, by ID
+	recipes     map[string]suggestEntry   // names of every recipe, by ID
+}
+
+// suggestEntry is a name that can be suggested
+type suggestEntry struct {
+	key        string // lowercase name with single spaces, what the query is compared with
+	suggestion Suggestion
+}
+
+// suggestIndex is the index used by /cravings/food/suggest
+var suggestIndex = &SuggestIndex{}
+
+// resetIngredients takes every ingredient out of the index
+func (idx *SuggestIndex) resetIngredients() {
+	idx.ingredients = map[string][]suggestEntry{}
+}
+
+// addIngredient adds the name and aliases of an ingredient to the index
+func (idx *SuggestIndex) addIngredient(ing Ingredient) {
+	entries := []suggestEntry{newSuggestEntry(ing.Name, Suggestion{Name: ing.Name, Type: caseing})}
+
+	for _, alias := range ing.Aliases {
+		entries = append(entries, newSuggestEntry(alias, Suggestion{Name: ing.Name, Type: caseing, Alias: alias}))
+	}
+
+	idx.ingredients[ing.ID] = entries
+}
+
+// removeIngredient takes the ingredient with the ID out of the index
+func (idx *SuggestIndex) removeIngredient(id string) {
+	delete(idx.ingredients, id)
+}
+
+// resetRecipes takes every recipe out of the index
+func (idx *SuggestIndex) resetRecipes() {
+	idx.recipes = map[string]suggestEntry{}
+}
+
+// addRecipe adds the name of a recipe to the index
+func (idx *SuggestIndex) addRecipe(rec Recipe) {
+	idx.recipes[rec.ID] = newSuggestEntry(rec.RecipeName, Suggestion{Name: rec.RecipeName, Type: caserec})
+}
+
+// removeRecipe takes the recipe with the ID out of the index
+func (idx *SuggestIndex) removeRecipe(id string) {
+	delete(idx.recipes, id)
+}
+
+// newSuggestEntry returns the entry suggesting suggestion for name
+func newSuggestEntry(name string, suggestion Suggestion) suggestEntry {
+	return suggestEntry{key: suggestKey(name), suggestion: suggestion}
+}
+
+// suggestTypos returns how many typos a query of the given length can have. It is less than for lookups,
+// since the query is only the start of a name
+func suggestTypos(length int) int {
+	if length < 8 {
+		return 1
+	}
+
+	return 2
+}
+
+// suggestKey returns the text queries are compared with, lowercase with single spaces
+func suggestKey(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Suggest returns at most limit names for what the user has typed so far. Names equal to q come first, then names
+// starting with q, then names with a word starting with q, and last names that are close to q with typos
+func (idx *SuggestIndex) Suggest(q string, limit int, w http.ResponseWriter) ([]Suggestion, error) {
+	err := readCache.rlock(true, true)
+	if err != nil {
+		return []Suggestion{}, err
+	}
+	defer readCache.mu.RUnlock()
+
+	entries := []suggestEntry{}
+
+	for _, names := range idx.ingredients {
+		entries = append(entries, names...)
+	}
+
+	for _, entry := range idx.recipes {
+		entries = append(entries, entry)
+	}
+
+	type match struct {
+		entry    suggestEntry
+		rank     int // 0 equal, 1 prefix, 2 word prefix, 3 typos
+		distance int
+	}
+
+	query := suggestKey(q)
+	matches := []match{}
+
+	for _, entry := range entries {
+		switch {
+		case entry.key == query:
+			matches = append(matches, match{entry: entry, rank: 0})
+		case strings.HasPrefix(entry.key, query):
+			matches = append(matches, match{entry: entry, rank: 1})
+		case strings.Contains(" "+entry.key, " "+query):
+			matches = append(matches, match{entry: entry, rank: 2})
+		case len([]rune(query)) >= 3: // too short queries are close to everything
+			// compare with the start of the name, since the user has not typed all of it yet
+			prefix := []rune(entry.key)
+			if len(prefix) > len([]rune(query)) {
+				prefix = prefix[:len([]rune(query))]
+			}
+
+			distance := EditDistance(query, string(prefix))
+			if distance <= suggestTypos(len([]rune(query))) {
+				matches = append(matches, match{entry: entry, rank: 3, distance: distance})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+
+		switch {
+		case a.rank != b.rank:
+			return a.rank < b.rank
+		case a.distance != b.distance:
+			return a.distance < b.distance
+		case len(a.entry.key) != len(b.entry.key): // shortest names first, they are closest to what was typed
+			return len(a.entry.key) < len(b.entry.key)
+		case a.entry.key != b.entry.key:
+			return a.entry.key < b.entry.key
+		default: // the same name of two ingredients or recipes, sorted so they are in the same order every time
+			return a.entry.suggestion.Type+a.entry.suggestion.Name < b.entry.suggestion.Type+b.entry.suggestion.Name
+		}
+	})
+
+	suggestions := []Suggestion{}
+	seen := map[string]bool{} // an ingredient is only suggested once, by its best name or alias
+
+	for _, m := range matches {
+		id := m.entry.suggestion.Type + "|" + m.entry.suggestion.Name
+		if seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		suggestions = append(suggestions, m.entry.suggestion)
+
+		if len(suggestions) == limit {
+			break
+		}
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,98 @@
+package cravings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSuggestIndex(t *testing.T) {
+	ingredients := []Ingredient{
+		{Name: "testsugar", Unit: "g", Quantity: 1},
+		{Name: "brown testsugar", Unit: "g", Quantity: 1},
+		{Name: "testsuggini", Unit: "g", Quantity: 1, Aliases: []string{"testsugcourgette"}},
+	}
+
+	for i := range ingredients {
+		err := DBSaveIngredient(&ingredients[i], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer DBDelete(ingredients[i].ID, IngredientCollection, nil)
+	}
+
+	rec := Recipe{RecipeName: "testsugar cookies"}
+
+	err := DBSaveRecipe(&rec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suggestions, err := suggestIndex.Suggest("TestSugar", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// exact name first, then names starting with the query, then a word starting with it
+	expected := []string{"testsugar", "testsugar cookies", "brown testsugar"}
+	if len(suggestions) < len(expected) {
+		t.Fatal("expected at least", expected, "got", suggestions)
+	}
+
+	for i := range expected {
+		if suggestions[i].Name != expected[i] {
+			t.Error("expected "+expected[i]+" as suggestion", i, "got", suggestions)
+		}
+	}
+
+	suggestions, _ = suggestIndex.Suggest("testsugcour", 10, nil) // alias
+	if len(suggestions) == 0 || suggestions[0].Name != "testsuggini" || suggestions[0].Alias != "testsugcourgette" {
+		t.Error("expected testsuggini by its alias, got", suggestions)
+	}
+
+	suggestions, _ = suggestIndex.Suggest("tsetsugar", 10, nil) // typo
+	if len(suggestions) == 0 || suggestions[0].Name != "testsugar" {
+		t.Error("expected testsugar for typo, got", suggestions)
+	}
+
+	err = DBDelete(rec.ID, RecipeCollection, nil) // the index should be rebuilt without the recipe
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suggestions, _ = suggestIndex.Suggest("testsugar c", 10, nil)
+	for _, s := range suggestions {
+		if s.Type == caserec {
+			t.Error("deleted recipe is still suggested", suggestions)
+		}
+	}
+
+	fmt.Println("testing SuggestIndex")
+}
+
+func TestHandlerSuggest(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	HandlerFood(w, httptest.NewRequest(http.MethodGet, "/cravings/food/suggest?q=mil", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatal(w.Code, w.Body.String())
+	}
+
+	suggestions := []Suggestion{}
+
+	err := json.NewDecoder(w.Body).Decode(&suggestions)
+	if err != nil {
+		t.Error(err)
+	}
+
+	w = httptest.NewRecorder()
+
+	HandlerFood(w, httptest.NewRequest(http.MethodGet, "/cravings/food/suggest", nil)) // no query
+
+	if w.Code != http.StatusBadRequest {
+		t.Error("expected bad request without query, got", w.Code)
+	}
+}
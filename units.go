@@ -0,0 +1,242 @@
+package cravings
+
+import (
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Dimensions a unit can measure
+const (
+	DimensionMass   = "mass"
+	DimensionVolume = "volume"
+	DimensionCount  = "count"
+)
+
+// Unit systems quantities can be displayed in
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+	UnitsOriginal = "original" // keep the units the quantities were given in
+)
+
+// Unit is a unit of measurement in the unit registry
+type Unit struct {
+	Name      string
+	Dimension string
+	Factor    float64 // How many of the dimension's reference unit (g, ml, pc) one of this unit is
+	Spoon     bool    // Measured with a spoon, so it is used for solids as well as liquids
+	System    string  // UnitsMetric or UnitsImperial, empty for units used by both
+}
+
+// unitRegistry is every unit that can be used, in the order they are listed to the user.
+// The units of a dimension in each system go from largest to smallest. Imperial units are US customary units
+var unitRegistry = []Unit{
+	{Name: "kg", Dimension: DimensionMass, Factor: 1000, System: UnitsMetric},
+	{Name: "g", Dimension: DimensionMass, Factor: 1, System: UnitsMetric},
+	{Name: "lb", Dimension: DimensionMass, Factor: 453.59237, System: UnitsImperial},
+	{Name: "oz", Dimension: DimensionMass, Factor: 28.349523125, System: UnitsImperial},
+	{Name: "l", Dimension: DimensionVolume, Factor: 1000, System: UnitsMetric},
+	{Name: "dl", Dimension: DimensionVolume, Factor: 100, System: UnitsMetric},
+	{Name: "cl", Dimension: DimensionVolume, Factor: 10, System: UnitsMetric},
+	{Name: "ml", Dimension: DimensionVolume, Factor: 1, System: UnitsMetric},
+	{Name: "pint", Dimension: DimensionVolume, Factor: 473.176473, System: UnitsImperial},
+	{Name: "cup", Dimension: DimensionVolume, Factor: 236.5882365, System: UnitsImperial},
+	{Name: "fl oz", Dimension: DimensionVolume, Factor: 29.5735295625, System: UnitsImperial},
+	{Name: "pc", Dimension: DimensionCount, Factor: 1},
+	{Name: "tablespoon", Dimension: DimensionVolume, Factor: 15, Spoon: true},
+	{Name: "teaspoon", Dimension: DimensionVolume, Factor: 5, Spoon: true},
+	{Name: "pinch", Dimension: DimensionVolume, Factor: 0.3125, Spoon: true}, // 1/16 teaspoon
+}
+
+// baseUnits is the canonical unit of each dimension, ingredients are saved with nutrients for 1 of it
+var baseUnits = map[string]string{
+	DimensionMass:   "g",
+	DimensionVolume: "l",
+	DimensionCount:  "pc",
+}
+
+// unitNames returns the names of all the units in the registry
+func unitNames() []string {
+	var names []string
+
+	for _, u := range unitRegistry {
+		names = append(names, u.Name)
+	}
+
+	return names
+}
+
+// LookupUnit returns the unit with the given name from the registry
+func LookupUnit(name string) (Unit, error) {
+	for _, u := range unitRegistry {
+		if u.Name == name {
+			return u, nil
+		}
+	}
+
+	return Unit{}, errors.New(name + " is not an allowed unit. Allowed units: " + strings.Join(AllowedUnit, ", "))
+}
+
+// BaseUnit returns the canonical unit for the dimension of the given unit, "g", "l" or "pc"
+func BaseUnit(name string) (string, error) {
+	u, err := LookupUnit(name)
+	if err != nil {
+		return "", err
+	}
+
+	return baseUnits[u.Dimension], nil
+}
+
+// Convert converts quantity from one unit to another. It is an error to convert between dimensions, i.e. kg to l
+func Convert(quantity float64, from string, to string) (float64, error) {
+	fromUnit, err := LookupUnit(from)
+	if err != nil {
+		return quantity, err
+	}
+
+	toUnit, err := LookupUnit(to)
+	if err != nil {
+		return quantity, err
+	}
+
+	if fromUnit.Dimension != toUnit.Dimension {
+		return quantity, errors.New("Can not convert " + from + " (" + fromUnit.Dimension + ") to " +
+			to + " (" + toUnit.Dimension + ")")
+	}
+
+	return quantity * fromUnit.Factor / toUnit.Factor, nil
+}
+
+// Measures are the properties of an ingredient used to convert between weight, volume and pieces.
+// A value of 0 means it is not known
+type Measures struct {
+	Density     float64 // g/ml
+	PieceWeight float64 // g
+}
+
+// ConvertWith converts quantity from one unit to another like Convert, and also between weight, volume and pieces
+// when the measures needed for it are known
+func ConvertWith(quantity float64, from string, to string, m Measures) (float64, error) {
+	fromUnit, err := LookupUnit(from)
+	if err != nil {
+		return quantity, err
+	}
+
+	toUnit, err := LookupUnit(to)
+	if err != nil {
+		return quantity, err
+	}
+
+	if fromUnit.Dimension != toUnit.Dimension {
+		if grams, ok := m.toGrams(quantity, fromUnit); ok {
+			if converted, ok := m.fromGrams(grams, toUnit); ok {
+				return converted, nil
+			}
+		}
+	}
+
+	return Convert(quantity, from, to)
+}
+
+// toGrams returns the weight in g of quantity of the unit, false if the measures needed are not known
+func (m Measures) toGrams(quantity float64, u Unit) (float64, bool) {
+	switch {
+	case u.Dimension == DimensionMass:
+		return quantity * u.Factor, true
+	case u.Dimension == DimensionVolume && m.Density > 0:
+		return quantity * u.Factor * m.Density, true // ml to g
+	case u.Dimension == DimensionCount && m.PieceWeight > 0:
+		return quantity * u.Factor * m.PieceWeight, true // pieces to g
+	}
+
+	return 0, false
+}
+
+// fromGrams returns grams in the unit, false if the measures needed are not known
+func (m Measures) fromGrams(grams float64, u Unit) (float64, bool) {
+	switch {
+	case u.Dimension == DimensionMass:
+		return grams / u.Factor, true
+	case u.Dimension == DimensionVolume && m.Density > 0:
+		return grams / m.Density / u.Factor, true
+	case u.Dimension == DimensionCount && m.PieceWeight > 0:
+		return grams / m.PieceWeight / u.Factor, true
+	}
+
+	return 0, false
+}
+
+// CanConvert returns true if from can be converted to to with the given measures
+func CanConvert(from string, to string, m Measures) bool {
+	_, err := ConvertWith(1, from, to, m)
+	return err == nil
+}
+
+// SameDimension returns true if the two units measure the same dimension
+func SameDimension(first string, second string) bool {
+	firstUnit, err := LookupUnit(first)
+	if err != nil {
+		return false
+	}
+
+	secondUnit, err := LookupUnit(second)
+	if err != nil {
+		return false
+	}
+
+	return firstUnit.Dimension == secondUnit.Dimension
+}
+
+// DisplayUnit returns the quantity in the unit of the system that gives the most readable number, i.e. 1000 g is
+// shown as 1 kg in metric and 2.2 lb in imperial, rounded. Pieces and spoons are kept in any system, and
+// UnitsOriginal only rounds the quantity
+func DisplayUnit(quantity float64, unit string, system string) (float64, string) {
+	u, err := LookupUnit(unit)
+	if err != nil || u.System == "" || system == UnitsOriginal {
+		return roundQuantity(quantity), unit
+	}
+
+	display := u
+
+	for _, candidate := range unitRegistry { // the largest unit the quantity is at least 1 of
+		if candidate.System != system || candidate.Dimension != u.Dimension || candidate.Spoon {
+			continue
+		}
+
+		display = candidate
+
+		if quantity*u.Factor/candidate.Factor >= 1 {
+			break
+		}
+	}
+
+	return roundQuantity(quantity * u.Factor / display.Factor), display.Name
+}
+
+// roundQuantity rounds to 2 decimals, or to 2 significant digits for quantities below 1
+func roundQuantity(quantity float64) float64 {
+	if quantity == 0 || math.Abs(quantity) >= 1 {
+		return math.Round(quantity*100) / 100
+	}
+
+	scale := math.Pow(10, 1-math.Floor(math.Log10(math.Abs(quantity))))
+
+	return math.Round(quantity*scale) / scale
+}
+
+// Display converts the quantity of the ingredient to the unit system, see DisplayUnit.
+// Nothing is changed if system is empty
+func (ing *Ingredient) Display(system string) {
+	if system != "" {
+		ing.Quantity, ing.Unit = DisplayUnit(ing.Quantity, ing.Unit, system)
+	}
+}
+
+// DisplayIngredients converts the quantities of all the ingredients to the unit system
+func DisplayIngredients(ingredients []Ingredient, system string) {
+	for i := range ingredients {
+		ingredients[i].Display(system)
+	}
+}
@@ -0,0 +1,175 @@
+package cravings
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	quantity, err := Convert(2.5, "kg", "g") // test convert within mass
+	if err != nil || quantity != 2500 {
+		t.Error("expected 2500 g, got", quantity, err)
+	}
+
+	quantity, err = Convert(3, "dl", "cl") // test convert within volume
+	if err != nil || quantity != 30 {
+		t.Error("expected 30 cl, got", quantity, err)
+	}
+
+	quantity, err = Convert(2, "tablespoon", "teaspoon") // test convert between spoons
+	if err != nil || quantity != 6 {
+		t.Error("expected 6 teaspoons, got", quantity, err)
+	}
+
+	quantity, err = Convert(1, "tablespoon", "ml") // spoons are volumes
+	if err != nil || quantity != 15 {
+		t.Error("expected 15 ml, got", quantity, err)
+	}
+
+	_, err = Convert(1, "kg", "l") // can not convert mass to volume, error is supposed to be sent
+	if err == nil {
+		t.Error("converted kg to l")
+	}
+
+	_, err = Convert(1, "g", "dl")
+	if err == nil {
+		t.Error("converted g to dl")
+	}
+
+	_, err = Convert(1, "bucket", "l") // unknown unit
+	if err == nil {
+		t.Error("converted unknown unit")
+	}
+
+	fmt.Println("testing Convert")
+}
+
+func TestBaseUnit(t *testing.T) {
+	for unit, expected := range map[string]string{"kg": "g", "cl": "l", "pc": "pc", "tablespoon": "l"} {
+		base, err := BaseUnit(unit)
+		if err != nil || base != expected {
+			t.Error("expected base unit "+expected+" for "+unit+", got", base, err)
+		}
+	}
+
+	fmt.Println("testing BaseUnit")
+}
+
+func TestUnitCheck(t *testing.T) {
+	if !UnitCheck("dl", "l") || !UnitCheck("kg", "g") || !UnitCheck("teaspoon", "g") {
+		t.Error("units of the same type were not accepted")
+	}
+
+	if UnitCheck("g", "l") || UnitCheck("ml", "g") || UnitCheck("pc", "g") || UnitCheck("bucket", "l") {
+		t.Error("units of different types were accepted")
+	}
+
+	fmt.Println("testing UnitCheck")
+}
+
+func TestConvertWith(t *testing.T) {
+	quantity, err := ConvertWith(2, "dl", "g", Measures{Density: 0.5}) // 200 ml of something weighing 0.5 g/ml
+	if err != nil || quantity != 100 {
+		t.Error("expected 100 g, got", quantity, err)
+	}
+
+	quantity, err = ConvertWith(1, "kg", "l", Measures{Density: 0.5})
+	if err != nil || quantity != 2 {
+		t.Error("expected 2 l, got", quantity, err)
+	}
+
+	quantity, err = ConvertWith(3, "dl", "cl", Measures{}) // same dimension does not need a density
+	if err != nil || quantity != 30 {
+		t.Error("expected 30 cl, got", quantity, err)
+	}
+
+	_, err = ConvertWith(1, "g", "dl", Measures{}) // no density, error is supposed to be sent
+	if err == nil {
+		t.Error("converted g to dl without a density")
+	}
+
+	_, err = ConvertWith(1, "pc", "g", Measures{Density: 0.5}) // density does not help for pieces
+	if err == nil {
+		t.Error("converted pc to g")
+	}
+
+	quantity, err = ConvertWith(2, "pc", "g", Measures{PieceWeight: 60}) // two eggs
+	if err != nil || quantity != 120 {
+		t.Error("expected 120 g, got", quantity, err)
+	}
+
+	quantity, err = ConvertWith(1, "dl", "pc", Measures{Density: 1, PieceWeight: 50}) // volume to pieces through weight
+	if err != nil || quantity != 2 {
+		t.Error("expected 2 pc, got", quantity, err)
+	}
+
+	if !CanConvert("g", "dl", Measures{Density: 0.5}) || CanConvert("g", "dl", Measures{}) {
+		t.Error("CanConvert does not match ConvertWith")
+	}
+
+	fmt.Println("testing ConvertWith")
+}
+
+func TestImperialUnits(t *testing.T) {
+	for _, c := range []struct {
+		quantity float64
+		from     string
+		to       string
+		expected float64
+	}{
+		{1, "lb", "oz", 16},
+		{1, "lb", "g", 453.59237},
+		{2, "cup", "ml", 473.176473},
+		{1, "pint", "fl oz", 16},
+		{1, "cup", "dl", 2.365882365},
+		{16, "pinch", "teaspoon", 1},
+	} {
+		quantity, err := Convert(c.quantity, c.from, c.to)
+		if err != nil || math.Abs(quantity-c.expected) > 0.000001 {
+			t.Error("expected", c.expected, c.to, "from", c.quantity, c.from, "got", quantity, err)
+		}
+	}
+
+	_, err := Convert(1, "oz", "fl oz") // ounces are weight, fluid ounces are volume
+	if err == nil {
+		t.Error("converted oz to fl oz")
+	}
+
+	fmt.Println("testing imperial units")
+}
+
+func TestDisplayUnit(t *testing.T) {
+	for _, c := range []struct {
+		quantity float64
+		unit     string
+		system   string
+		expected float64
+		unitTo   string
+	}{
+		{1000, "g", UnitsMetric, 1, "kg"},
+		{0.25, "l", UnitsMetric, 2.5, "dl"},
+		{1000, "g", UnitsImperial, 2.2, "lb"},
+		{100, "g", UnitsImperial, 3.53, "oz"},
+		{2, "cup", UnitsMetric, 4.73, "dl"},
+		{2, "tablespoon", UnitsMetric, 2, "tablespoon"}, // spoons are kept
+		{3, "pc", UnitsImperial, 3, "pc"},
+		{1000, "g", UnitsOriginal, 1000, "g"},
+		{1.23456, "kg", UnitsOriginal, 1.23, "kg"},
+		{0.012345, "g", UnitsMetric, 0.012, "g"},
+	} {
+		quantity, unit := DisplayUnit(c.quantity, c.unit, c.system)
+		if quantity != c.expected || unit != c.unitTo {
+			t.Error("expected", c.expected, c.unitTo, "for", c.quantity, c.unit, "in", c.system, "got", quantity, unit)
+		}
+	}
+
+	ing := Ingredient{Quantity: 1500, Unit: "ml"}
+
+	ing.Display("") // not set, should not be changed
+	if ing.Quantity != 1500 || ing.Unit != "ml" {
+		t.Error("ingredient was changed without a unit system", ing)
+	}
+
+	fmt.Println("testing DisplayUnit")
+}
@@ -0,0 +1,248 @@
+package cravings
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DatasetColumns names the columns of a food composition CSV file that are imported
+type DatasetColumns struct {
+	Name         string
+	ID           string  // Optional column with a number identifying the food, added to names that are taken
+	Energy       string  // kcal
+	Fat          string  // g
+	Protein      string  // g
+	Carbohydrate string  // g
+	Sugar        string  // g
+	Unit         string  // Optional column with the unit the values are given for, "g" if not set
+	Per          float64 // Quantity of the unit the values are given for, i.e. 100 for per 100 g
+	Abbreviated  bool    // Names are USDA short descriptions, see usdaName
+}
+
+// USDAColumns are the columns of the USDA SR Legacy abbreviated export (ABBREV.csv), values per 100 g.
+// FoodData Central exports have the nutrients in another file than the foods, and are not supported
+var USDAColumns = DatasetColumns{
+	Name:         "Shrt_Desc",
+	ID:           "NDB_No",
+	Energy:       "Energ_Kcal",
+	Fat:          "Lipid_Tot_(g)",
+	Protein:      "Protein_(g)",
+	Carbohydrate: "Carbohydrt_(g)",
+	Sugar:        "Sugar_Tot_(g)",
+	Per:          100,
+	Abbreviated:  true,
+}
+
+// usdaAbbreviations are the words shortened in USDA short descriptions
+var usdaAbbreviations = map[string]string{
+	"bkd": "baked", "bld": "boiled", "bnls": "boneless", "choc": "chocolate", "ckd": "cooked",
+	"cnd": "canned", "commly": "commercially", "conc": "concentrate", "dehyd": "dehydrated", "drnd": "drained",
+	"enr": "enriched", "flr": "flour", "frsh": "fresh", "frz": "frozen", "hvy": "heavy", "lofat": "lowfat",
+	"ltd": "limited", "pdr": "powder", "prep": "prepared", "rts": "ready-to-serve", "sltd": "salted",
+	"sml": "small", "swtnd": "sweetened", "unenr": "unenriched", "unprep": "unprepared", "unsltd": "unsalted",
+	"unswtnd": "unsweetened", "veg": "vegetable", "w/": "with", "w/o": "without", "whl": "whole", "wo/": "without",
+}
+
+// usdaName returns a name without commas and abbreviations for a USDA short description, i.e. "wheat flour" for
+// "WHEAT FLR,WHITE,ALL-PURPOSE,ENR". It is the first part of the description, with as many of the next parts as
+// are needed for a name that is not in taken. If every part is needed and the name is still taken, id is added
+func usdaName(desc string, id string, taken map[string]bool) string {
+	var words []string
+
+	for _, part := range strings.Split(strings.ToLower(desc), ",") {
+		for _, word := range strings.Fields(part) {
+			if expanded, ok := usdaAbbreviations[word]; ok {
+				word = expanded
+			}
+
+			words = append(words, word)
+		}
+
+		if name := strings.Join(words, " "); name != "" && !taken[name] {
+			return name
+		}
+	}
+
+	name := strings.Join(words, " ")
+
+	if taken[name] && id != "" {
+		name += " " + id
+	}
+
+	return name
+}
+
+// ImportNutrientDataset reads a food composition CSV file and returns the ingredients in it,
+// normalised to nutrients for 1 g or 1 l like RegisterIngredient saves them
+func ImportNutrientDataset(r io.Reader, columns DatasetColumns) ([]Ingredient, error) {
+	var ingredients []Ingredient
+
+	reader := csv.NewReader(r)
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1 // some exports have trailing empty fields
+
+	header, err := reader.Read()
+	if err != nil {
+		return ingredients, errors.Wrap(err, "Could not read header of dataset")
+	}
+
+	position := map[string]int{} // position of each column from the header
+
+	for i, name := range header {
+		position[strings.TrimSpace(name)] = i
+	}
+
+	for _, c := range []string{columns.Name, columns.Energy, columns.Fat, columns.Protein,
+		columns.Carbohydrate, columns.Sugar} {
+		if _, ok := position[c]; ok {
+			continue
+		}
+
+		if _, ok := position["fdc_id"]; ok { // the foods file of a FoodData Central export
+			return ingredients, errors.New("Dataset is a FoodData Central export, only the SR Legacy " +
+				"abbreviated export (ABBREV.csv) can be imported")
+		}
+
+		return ingredients, errors.New("Dataset is missing column " + c)
+	}
+
+	if columns.Per <= 0 {
+		columns.Per = 1
+	}
+
+	seen := map[string]bool{} // the first row wins if a name is in the dataset more than once
+	line := 1
+
+	for {
+		row, err := reader.Read()
+		line++
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return ingredients, errors.Wrap(err, "Could not read dataset line "+strconv.Itoa(line))
+		}
+
+		// value returns the number in column c, missing values count as 0
+		value := func(c string) (float64, error) {
+			i := position[c]
+			if i >= len(row) || strings.TrimSpace(row[i]) == "" {
+				return 0, nil
+			}
+
+			return strconv.ParseFloat(strings.TrimSpace(row[i]), 64)
+		}
+
+		ing := Ingredient{Name: strings.ToLower(strings.TrimSpace(row[position[columns.Name]])), Quantity: 1}
+
+		if columns.Abbreviated { // the description is kept as an alias, so the ingredient can be found by it
+			ing.Aliases = []string{ing.Name}
+
+			id := ""
+			if i, ok := position[columns.ID]; ok && columns.ID != "" && i < len(row) {
+				id = strings.TrimSpace(row[i])
+			}
+
+			ing.Name = usdaName(ing.Name, id, seen)
+		}
+
+		if ing.Name == "" || seen[ing.Name] {
+			continue
+		}
+
+		// Find how much of the base unit the values are given for
+		per := Ingredient{Quantity: columns.Per, Unit: "g"}
+
+		if i, ok := position[columns.Unit]; ok && columns.Unit != "" && i < len(row) {
+			per.Unit = strings.ToLower(strings.TrimSpace(row[i]))
+		}
+
+		unit, err := LookupUnit(per.Unit)
+		if err != nil || (unit.Dimension != DimensionMass && unit.Dimension != DimensionVolume) {
+			return ingredients, errors.New("Dataset line " + strconv.Itoa(line) + ": " + per.Unit +
+				" is not a unit of weight or volume")
+		}
+
+		_ = ConvertUnit(&per, baseUnits[unit.Dimension]) // same dimension, can not fail
+
+		ing.Unit = per.Unit
+
+		var values [5]float64
+
+		for n, c := range []string{columns.Energy, columns.Fat, columns.Carbohydrate, columns.Sugar, columns.Protein} {
+			values[n], err = value(c)
+			if err != nil {
+				return ingredients, errors.New("Dataset line " + strconv.Itoa(line) + ": " + c + " is not a number")
+			}
+
+			values[n] /= per.Quantity // values for 1 g or 1 l
+		}
+
+		ing.Nutrients = NewTotalNutrients(values[0], values[1], values[2], values[3], values[4])
+		ing.Calories = values[0]
+
+		if ing.Unit == "g" {
+			ing.Weight = 1
+		}
+
+		seen[ing.Name] = true
+		ingredients = append(ingredients, ing)
+	}
+
+	return ingredients, nil
+}
+
+// ImportIngredients saves the ingredients that are not already in the database, and returns how many were saved
+func ImportIngredients(ingredients []Ingredient) (int, error) {
+	saved := 0
+
+	existing, err := DBReadAllIngredients(nil)
+	if err != nil {
+		return saved, err
+	}
+
+	names := map[string]bool{}
+
+	for _, i := range existing { // compared like ingredients are looked up, so plurals are not imported twice
+		names[NormalizeName(i.Name)] = true
+	}
+
+	for i := range ingredients {
+		if names[NormalizeName(ingredients[i].Name)] {
+			continue
+		}
+
+		err = DBSaveIngredient(&ingredients[i], nil)
+		if err != nil {
+			return saved, errors.Wrap(err, "Could not save ingredient "+ingredients[i].Name)
+		}
+
+		names[NormalizeName(ingredients[i].Name)] = true
+		saved++
+	}
+
+	return saved, nil
+}
+
+// ImportNutrientFile imports a USDA SR Legacy abbreviated CSV file to the ingredients collection
+func ImportNutrientFile(file string) (int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, errors.Wrap(err, "Could not open dataset")
+	}
+	defer f.Close()
+
+	ingredients, err := ImportNutrientDataset(f, USDAColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	return ImportIngredients(ingredients)
+}
@@ -0,0 +1,97 @@
+package cravings
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestImportNutrientDataset(t *testing.T) {
+	dataset := "NDB_No,Shrt_Desc,Water_(g),Energ_Kcal,Protein_(g),Lipid_Tot_(g),Carbohydrt_(g),Sugar_Tot_(g)\n" +
+		"01077,\"MILK,WHL,3.25% MILKFAT,W/ ADDED VIT D\",88.13,61,3.15,3.25,4.80,5.05\n" +
+		"20081,\"WHEAT FLR,WHITE,ALL-PURPOSE,ENR\",11.92,364,10.33,0.98,76.31,\n" +
+		"20080,\"WHEAT FLR,WHOLE-GRAIN\",10.74,340,13.21,2.50,71.97,0.41\n" +
+		"20634,\"WHEAT FLR,WHOLE-GRAIN\",10.74,340,13.21,2.50,71.97,0.41\n"
+
+	ingredients, err := ImportNutrientDataset(strings.NewReader(dataset), USDAColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ingredients) != 4 {
+		t.Fatal("expected 4 ingredients, got", len(ingredients))
+	}
+
+	flour := ingredients[1]
+
+	if flour.Name != "wheat flour" || flour.Unit != "g" || flour.Quantity != 1 {
+		t.Error("wrong ingredient", flour)
+	}
+
+	if len(flour.Aliases) != 1 || flour.Aliases[0] != "wheat flr,white,all-purpose,enr" {
+		t.Error("USDA description should be an alias", flour.Aliases)
+	}
+
+	if ingredients[0].Name != "milk" || ingredients[2].Name != "wheat flour whole-grain" {
+		t.Error("expected milk and wheat flour whole-grain, got", ingredients[0].Name, ingredients[2].Name)
+	}
+
+	if ingredients[3].Name != "wheat flour whole-grain 20634" { // every part of the name is taken
+		t.Error("expected the NDB number after a taken name, got", ingredients[3].Name)
+	}
+
+	// values are per 100 g in the dataset, and per 1 g in the database
+	if math.Abs(flour.Calories-3.64) > 1e-9 || math.Abs(flour.Nutrients.Carbohydrate.Quantity-0.7631) > 1e-9 {
+		t.Error("nutrients were not normalised to 1 g", flour.Nutrients)
+	}
+
+	if flour.Nutrients.Sugar.Quantity != 0 || flour.Nutrients.Energy.Label != "Energy" {
+		t.Error("missing sugar value should be 0", flour.Nutrients)
+	}
+
+	// dataset with values per 100 ml in its own unit column
+	columns := DatasetColumns{Name: "name", Energy: "kcal", Fat: "fat", Protein: "protein",
+		Carbohydrate: "carbs", Sugar: "sugar", Unit: "unit", Per: 100}
+	dataset = "name,kcal,fat,protein,carbs,sugar,unit\nOrange juice,45,0.2,0.7,10.4,8.4,ml\n"
+
+	ingredients, err = ImportNutrientDataset(strings.NewReader(dataset), columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ingredients[0].Unit != "l" || math.Abs(ingredients[0].Calories-450) > 1e-9 {
+		t.Error("nutrients were not normalised to 1 l", ingredients[0])
+	}
+
+	_, err = ImportNutrientDataset(strings.NewReader("name,kcal\nmilk,61\n"), USDAColumns) // missing columns
+	if err == nil {
+		t.Error("imported dataset with missing columns")
+	}
+
+	_, err = ImportNutrientDataset(strings.NewReader("fdc_id,data_type,description\n"), USDAColumns)
+	if err == nil || !strings.Contains(err.Error(), "FoodData Central") {
+		t.Error("FoodData Central export should not be imported", err)
+	}
+
+	fmt.Println("testing ImportNutrientDataset")
+}
+
+func TestImportIngredients(t *testing.T) {
+	original := Database
+	defer UseDatabase(original) // restore database used by the other tests
+
+	UseDatabase(NewMemoryDatabase())
+	_ = Database.SaveIngredient(&Ingredient{Name: "milk", Unit: "l"})
+
+	saved, err := ImportIngredients([]Ingredient{{Name: "milk", Unit: "l"}, {Name: "flour", Unit: "g"}})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if saved != 1 { // milk is already in the database
+		t.Error("expected 1 saved ingredient, got", saved)
+	}
+
+	fmt.Println("testing ImportIngredients")
+}